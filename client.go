@@ -0,0 +1,69 @@
+package main
+
+import (
+	"github.com/draganm/event-buffer/client"
+	"github.com/urfave/cli/v2"
+)
+
+var tokenFlag = &cli.StringFlag{
+	Name:    "token",
+	EnvVars: []string{"EVENT_BUFFER_TOKEN"},
+	Usage:   "token authenticating at the server",
+}
+
+// clientTLSFlags configure TLS of the connections to a running server, see serverClient.
+var clientTLSFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "ca-file",
+		Usage: "CA certificates verifying the server",
+	},
+	&cli.StringFlag{
+		Name:  "cert-file",
+		Usage: "client certificate presented to the server",
+	},
+	&cli.StringFlag{
+		Name:  "key-file",
+		Usage: "private key of the client certificate",
+	},
+}
+
+// clientFlags are the flags of the commands interacting with a running server.
+var clientFlags = append([]cli.Flag{
+	&cli.StringFlag{
+		Name:    "server",
+		EnvVars: []string{"EVENT_BUFFER_URL"},
+		Value:   "http://localhost:5566",
+		Usage:   "base URL of the server",
+	},
+	tokenFlag,
+}, clientTLSFlags...)
+
+// serverClient returns the client of the --server flag.
+func serverClient(c *cli.Context) (*client.Client, error) {
+	opts := []client.Option{}
+	if c.String("token") != "" {
+		opts = append(opts, client.WithToken(c.String("token")))
+	}
+
+	if c.String("ca-file") != "" || c.String("cert-file") != "" || c.String("key-file") != "" {
+		cfg, err := client.LoadTLSConfig(c.String("ca-file"), c.String("cert-file"), c.String("key-file"))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, client.WithTLSConfig(cfg))
+	}
+
+	return client.New(c.String("server"), opts...)
+}
+
+// topicClient returns the client of the topic in the --topic flag.
+func topicClient(c *cli.Context) (*client.Client, error) {
+	cl, err := serverClient(c)
+	if err != nil {
+		return nil, err
+	}
+	if c.String("topic") == "" {
+		return cl, nil
+	}
+	return cl.Topic(c.String("topic")), nil
+}
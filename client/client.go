@@ -10,58 +10,153 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
+
+	"github.com/gofrs/uuid"
 )
 
 type Client struct {
-	eventsURL *url.URL
+	baseURL *url.URL
+	// topicURL is the base URL for the default topic
+	// and <base>/topics/<name> for named topics
+	topicURL   *url.URL
+	eventsURL  *url.URL
+	httpClient *http.Client
+	retry      RetryPolicy
+	breaker    *circuitBreaker
+	token      string
 }
 
-func New(baseURL string) (*Client, error) {
+func New(baseURL string, opts ...Option) (*Client, error) {
 	u, err := url.Parse(baseURL)
 	if err != nil {
 		return nil, fmt.Errorf("could not parse base URL: %w", err)
 	}
 	eventsURL := u.JoinPath("events")
 
-	return &Client{eventsURL: eventsURL}, nil
+	c := &Client{
+		baseURL:    u,
+		topicURL:   u,
+		eventsURL:  eventsURL,
+		httpClient: http.DefaultClient,
+		retry:      DefaultRetryPolicy,
+	}
+
+	for _, o := range opts {
+		o(c)
+	}
+
+	return c, nil
+
+}
 
+// Topic returns a client that sends and polls events of the named topic.
+func (c *Client) Topic(name string) *Client {
+	cc := *c
+	cc.topicURL = c.baseURL.JoinPath("topics", name)
+	cc.eventsURL = cc.topicURL.JoinPath("events")
+	return &cc
 }
 
-func (c *Client) SendEvents(ctx context.Context, events []any) error {
+// PublishedEvent is an event stored by the server.
+type PublishedEvent struct {
+	ID string `json:"id"`
+	// Time is derived from the UUIDv6 ID.
+	Time time.Time `json:"time"`
+}
 
-	d, err := json.Marshal(events)
+// SendEvents publishes the events and returns the IDs assigned to them.
+// The events are sent once, unless the retry policy enables RetryPublishes.
+func (c *Client) SendEvents(ctx context.Context, events []any) ([]PublishedEvent, error) {
+	return c.publish(ctx, events)
+}
+
+func hasIdempotencyKey(body any) bool {
+	b, isBatch := body.(Batch)
+	return isBatch && b.IdempotencyKey != ""
+}
+
+func (c *Client) publish(ctx context.Context, body any) ([]PublishedEvent, error) {
+
+	d, err := json.Marshal(body)
 	if err != nil {
-		return fmt.Errorf("could not marshal events: %w", err)
+		return nil, fmt.Errorf("could not marshal events: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", c.eventsURL.String(), bytes.NewReader(d))
 	if err != nil {
-		return fmt.Errorf("could not create request: %w", err)
+		return nil, fmt.Errorf("could not create request: %w", err)
 	}
 
 	req.Header.Set("content-type", "application/json")
+	req.Header.Set("accept", "application/json")
 
-	res, err := http.DefaultClient.Do(req)
+	if c.retry.RetryPublishes && c.retry.MaxAttempts > 1 && !hasIdempotencyKey(body) {
+		// retried requests must not store the events again
+		id, err := uuid.NewV4()
+		if err != nil {
+			return nil, fmt.Errorf("could not generate idempotency key: %w", err)
+		}
+		req.Header.Set("idempotency-key", id.String())
+	}
+
+	res, err := c.doIdempotent(req, req.Header.Get("idempotency-key") != "" || hasIdempotencyKey(body))
 	if err != nil {
-		return fmt.Errorf("could not perform request: %w", err)
+		return nil, fmt.Errorf("could not perform request: %w", err)
 	}
 
 	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
-		rd, _ := io.ReadAll(res.Body)
-		return fmt.Errorf("unexpected status %s: %s", res.Status, string(rd))
+		return nil, newStatusError(res)
 	}
 
-	return nil
+	resp := struct {
+		Events []PublishedEvent `json:"events"`
+	}{}
+
+	err = json.NewDecoder(res.Body).Decode(&resp)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode response: %w", err)
+	}
+
+	return resp.Events, nil
 }
 
-type event struct {
-	ID      string
-	Payload json.RawMessage
+// Event is an event read from the buffer.
+type Event struct {
+	ID string `json:"id"`
+	// Time is derived from the UUIDv6 ID.
+	Time    time.Time         `json:"time"`
+	Payload json.RawMessage   `json:"payload"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Key is the optional partition/entity key of the event.
+	Key         string `json:"key,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	// ReceivedAt is the time the server received the event.
+	// It is zero for events stored before the server supported metadata.
+	ReceivedAt time.Time `json:"receivedAt"`
 }
 
-func (e *event) UnmarshalJSON(p []byte) error {
+// envelopeMediaType requests events with their metadata instead of [id, payload] tuples.
+const envelopeMediaType = "application/vnd.event-buffer.envelope+json"
+
+// cursorHeader holds the ID of the last event examined by a poll request.
+const cursorHeader = "Event-Buffer-Cursor"
+
+// UnmarshalJSON accepts both the envelope object and the [id, payload] tuple format.
+func (e *Event) UnmarshalJSON(p []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(p), []byte("{")) {
+		type envelope Event
+		env := envelope{}
+		err := json.Unmarshal(p, &env)
+		if err != nil {
+			return fmt.Errorf("could not unmarshal envelope: %w", err)
+		}
+		*e = Event(env)
+		return nil
+	}
+
 	parts := []json.RawMessage{}
 	err := json.Unmarshal(p, &parts)
 
@@ -87,60 +182,106 @@ func (e *event) UnmarshalJSON(p []byte) error {
 
 var errTimeout = errors.New("timeout")
 
+// StatusError is returned when the server responds with an unexpected status.
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %s: %s", e.Status, e.Body)
+}
+
+func newStatusError(res *http.Response) *StatusError {
+	rd, _ := io.ReadAll(res.Body)
+	return &StatusError{StatusCode: res.StatusCode, Status: res.Status, Body: string(rd)}
+}
+
 func (c *Client) PollForEvents(ctx context.Context, lastID string, limit int, evts any) ([]string, error) {
+	q := url.Values{}
+	q.Set("limit", strconv.FormatInt(int64(limit), 10))
+	q.Set("after", lastID)
+	return c.poll(ctx, q, evts)
+}
+
+// Poll returns up to limit events with their metadata, published after the event with the given ID.
+// If there are no such events, it waits until new events are published.
+func (c *Client) Poll(ctx context.Context, lastID string, limit int) ([]Event, error) {
+	q := url.Values{}
+	q.Set("limit", strconv.FormatInt(int64(limit), 10))
+	q.Set("after", lastID)
+	return c.pollEvents(ctx, q)
+}
+
+// ReadEvents returns up to limit events with their metadata, published after the event with the given ID.
+// Unlike Poll, it doesn't wait for new events and returns no events at the end of the topic.
+func (c *Client) ReadEvents(ctx context.Context, lastID string, limit int) ([]Event, error) {
+	q := url.Values{}
+	q.Set("limit", strconv.FormatInt(int64(limit), 10))
+	q.Set("after", lastID)
+	q.Set("wait", "false")
+	events, _, err := c.pollForEvents(ctx, q)
+	return events, err
+}
+
+// PollFiltered returns up to limit events published after the event with the given ID,
+// whose payloads match all filters. Filters have the form <path>:<op>:<value>,
+// for example "type:eq:order", "status:in:new,paid", "name:prefix:a" or "amount:gte:100".
+// If there are no such events, it waits until matching events are published.
+// The returned cursor is the ID of the last event examined by the server,
+// polling on from the cursor skips the events that didn't match.
+func (c *Client) PollFiltered(ctx context.Context, lastID string, limit int, filters ...string) ([]Event, string, error) {
+	q := url.Values{}
+	q.Set("limit", strconv.FormatInt(int64(limit), 10))
+	q["filter"] = filters
+
+	cursor := lastID
+
 	for {
-		ids, err := c.pollForEvents(ctx, lastID, limit, evts)
+		q.Set("after", cursor)
+		events, next, err := c.pollForEvents(ctx, q)
+		if next != "" {
+			cursor = next
+		}
 
 		if err == errTimeout {
 			continue
 		}
 
 		if err != nil {
-			return nil, err
+			return nil, cursor, err
 		}
 
-		return ids, nil
+		return events, cursor, nil
 	}
 }
 
-func (c *Client) pollForEvents(ctx context.Context, lastID string, limit int, evts any) ([]string, error) {
-	uc := *c.eventsURL
-
-	u := &uc
-	q := u.Query()
-	q.Set("limit", strconv.FormatInt(int64(limit), 10))
-	q.Set("after", lastID)
-	u.RawQuery = q.Encode()
-	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
-	if err != nil {
-		return nil, fmt.Errorf("could not create request: %w", err)
-	}
-
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("could not perform request: %w", err)
-	}
+func (c *Client) pollEvents(ctx context.Context, q url.Values) ([]Event, error) {
+	for {
+		events, _, err := c.pollForEvents(ctx, q)
 
-	defer res.Body.Close()
+		if err == errTimeout {
+			continue
+		}
 
-	if res.StatusCode == http.StatusRequestTimeout {
-		return nil, errTimeout
-	}
+		if err != nil {
+			return nil, err
+		}
 
-	if res.StatusCode != http.StatusOK {
-		rd, _ := io.ReadAll(res.Body)
-		return nil, fmt.Errorf("unexpected status %s: %s", res.Status, string(rd))
+		return events, nil
 	}
+}
 
-	resp := []event{}
-	err = json.NewDecoder(res.Body).Decode(&resp)
+func (c *Client) poll(ctx context.Context, q url.Values, evts any) ([]string, error) {
+	events, err := c.pollEvents(ctx, q)
 	if err != nil {
-		return nil, fmt.Errorf("could not decode response: %w", err)
+		return nil, err
 	}
 
-	payloads := make([]json.RawMessage, len(resp))
+	payloads := make([]json.RawMessage, len(events))
 
-	for i, e := range resp {
+	for i, e := range events {
 		payloads[i] = e.Payload
 	}
 
@@ -155,10 +296,76 @@ func (c *Client) pollForEvents(ctx context.Context, lastID string, limit int, ev
 		return nil, fmt.Errorf("could not unmarshal events: %w", err)
 	}
 
-	ids := make([]string, len(resp))
-	for i, evt := range resp {
+	ids := make([]string, len(events))
+	for i, evt := range events {
 		ids[i] = evt.ID
 	}
 
 	return ids, nil
 }
+
+// pollForEvents performs a single poll request.
+// It returns the cursor sent by the server also when the request timed out.
+func (c *Client) pollForEvents(ctx context.Context, q url.Values) ([]Event, string, error) {
+	uc := *c.eventsURL
+
+	u := &uc
+	u.RawQuery = q.Encode()
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not create request: %w", err)
+	}
+
+	req.Header.Set("accept", envelopeMediaType)
+
+	res, err := c.do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not perform request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	cursor := res.Header.Get(cursorHeader)
+
+	if res.StatusCode == http.StatusRequestTimeout {
+		return nil, cursor, errTimeout
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, cursor, newStatusError(res)
+	}
+
+	resp := []Event{}
+	err = json.NewDecoder(res.Body).Decode(&resp)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not decode response: %w", err)
+	}
+
+	return resp, cursor, nil
+}
+
+// Batch is a batch of events published together.
+type Batch struct {
+	// IdempotencyKey identifies the batch. Sending a batch with an already used key
+	// returns the IDs assigned when the batch was first sent, without storing the events again.
+	IdempotencyKey string       `json:"idempotencyKey,omitempty"`
+	Events         []BatchEvent `json:"events"`
+}
+
+type BatchEvent struct {
+	Payload any               `json:"payload"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Key is the optional partition/entity key of the event.
+	// In compacted topics an event with a key and a nil payload is a tombstone deleting the key.
+	Key         string `json:"key,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	// IdempotencyKey identifies the event. Sending an event with an already used key
+	// returns the ID assigned when the event was first sent, without storing it again.
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
+}
+
+// SendBatch publishes the batch and returns the IDs assigned to its events.
+// Retrying a batch with idempotency keys after a failure does not duplicate the events.
+func (c *Client) SendBatch(ctx context.Context, batch Batch) ([]PublishedEvent, error) {
+	return c.publish(ctx, batch)
+}
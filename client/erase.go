@@ -0,0 +1,147 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Selection selects events by their IDs, by filters on their payloads (see PollFiltered) or both.
+type Selection struct {
+	IDs     []string
+	Filters []string
+	// Reason is required and stored in the audit record.
+	Reason string
+}
+
+// AuditRecord records a deletion or redaction of events.
+type AuditRecord struct {
+	ID        string    `json:"id"`
+	Time      time.Time `json:"time"`
+	Operation string    `json:"operation"`
+	Topic     string    `json:"topic"`
+	Principal string    `json:"principal,omitempty"`
+	Reason    string    `json:"reason"`
+	IDs       []string  `json:"ids,omitempty"`
+	// Filter are the filters of the selection with their values replaced by SHA-256 hashes,
+	// in the form <path>:<op>:sha256:<hex encoded hash>.
+	Filter []string `json:"filter,omitempty"`
+	Fields []string `json:"fields,omitempty"`
+	// Affected are the IDs of the deleted or redacted events.
+	Affected []string `json:"affected"`
+}
+
+type eraseRequest struct {
+	IDs         []string `json:"ids,omitempty"`
+	Filter      []string `json:"filter,omitempty"`
+	Fields      []string `json:"fields,omitempty"`
+	Replacement any      `json:"replacement,omitempty"`
+	Reason      string   `json:"reason"`
+}
+
+// DeleteEvents deletes the selected events. It requires the admin scope.
+func (c *Client) DeleteEvents(ctx context.Context, sel Selection) (AuditRecord, error) {
+	return c.erase(ctx, "delete", eraseRequest{IDs: sel.IDs, Filter: sel.Filters, Reason: sel.Reason})
+}
+
+// RedactEvents replaces the fields of the selected events' payloads with the replacement,
+// keeping their IDs and order. Fields are paths in the syntax of filters, e.g. "user.email".
+// A nil replacement replaces the fields with "[redacted]". It requires the admin scope.
+func (c *Client) RedactEvents(ctx context.Context, sel Selection, fields []string, replacement any) (AuditRecord, error) {
+	return c.erase(ctx, "redact", eraseRequest{IDs: sel.IDs, Filter: sel.Filters, Fields: fields, Replacement: replacement, Reason: sel.Reason})
+}
+
+func (c *Client) erase(ctx context.Context, op string, er eraseRequest) (AuditRecord, error) {
+	d, err := json.Marshal(er)
+	if err != nil {
+		return AuditRecord{}, fmt.Errorf("could not marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.eventsURL.JoinPath(op).String(), bytes.NewReader(d))
+	if err != nil {
+		return AuditRecord{}, fmt.Errorf("could not create request: %w", err)
+	}
+
+	req.Header.Set("content-type", "application/json")
+
+	res, err := c.do(req)
+	if err != nil {
+		return AuditRecord{}, fmt.Errorf("could not perform request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return AuditRecord{}, newStatusError(res)
+	}
+
+	rec := AuditRecord{}
+	err = json.NewDecoder(res.Body).Decode(&rec)
+	if err != nil {
+		return AuditRecord{}, fmt.Errorf("could not decode response: %w", err)
+	}
+
+	return rec, nil
+}
+
+// auditPageSize is the number of audit records read per request, the largest limit allowed by the server.
+const auditPageSize = 1000
+
+// AuditRecords returns the records of deletions and redactions of the topic's events, oldest first.
+// It requires the admin scope.
+func (c *Client) AuditRecords(ctx context.Context) ([]AuditRecord, error) {
+	all := []AuditRecord{}
+	lastID := ""
+	for {
+		records, err := c.AuditRecordsAfter(ctx, lastID, auditPageSize)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, records...)
+		if len(records) < auditPageSize {
+			return all, nil
+		}
+		lastID = records[len(records)-1].ID
+	}
+}
+
+// AuditRecordsAfter returns up to limit audit records of the topic stored after the record with the given ID, oldest first.
+// It requires the admin scope.
+func (c *Client) AuditRecordsAfter(ctx context.Context, lastID string, limit int) ([]AuditRecord, error) {
+	u := c.topicURL.JoinPath("audit")
+	q := url.Values{}
+	q.Set("limit", strconv.Itoa(limit))
+	if lastID != "" {
+		q.Set("after", lastID)
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not perform request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, newStatusError(res)
+	}
+
+	records := []AuditRecord{}
+	err = json.NewDecoder(res.Body).Decode(&records)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode response: %w", err)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+func (c *Client) groupURL(group string) *url.URL {
+	return c.topicURL.JoinPath("groups", group)
+}
+
+// PollForGroupEvents polls for events after the last offset committed by the consumer group.
+// Polled events are not committed automatically, see CommitOffset and PollAndCommit.
+func (c *Client) PollForGroupEvents(ctx context.Context, group string, limit int, evts any) ([]string, error) {
+	q := url.Values{}
+	q.Set("limit", strconv.FormatInt(int64(limit), 10))
+	q.Set("group", group)
+	return c.poll(ctx, q, evts)
+}
+
+// CommitOffset stores the ID of the last event processed by the consumer group.
+// The ID must not be before the committed offset, see ResetOffset.
+func (c *Client) CommitOffset(ctx context.Context, group, id string) error {
+	return c.commit(ctx, group, commitRequest{ID: id})
+}
+
+// ResetOffset moves the offset of the consumer group to the event with the ID,
+// also before the committed offset so the group reads events again.
+func (c *Client) ResetOffset(ctx context.Context, group, id string) error {
+	return c.commit(ctx, group, commitRequest{ID: id, Reset: true})
+}
+
+type commitRequest struct {
+	ID    string `json:"id"`
+	Reset bool   `json:"reset,omitempty"`
+}
+
+func (c *Client) commit(ctx context.Context, group string, cr commitRequest) error {
+	d, err := json.Marshal(cr)
+	if err != nil {
+		return fmt.Errorf("could not marshal commit request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.groupURL(group).JoinPath("commit").String(), bytes.NewReader(d))
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+
+	req.Header.Set("content-type", "application/json")
+
+	res, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("could not perform request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return newStatusError(res)
+	}
+
+	return nil
+}
+
+// CommittedOffset returns the ID of the last event committed by the consumer group,
+// or an empty string if the group has not committed any events yet.
+func (c *Client) CommittedOffset(ctx context.Context, group string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.groupURL(group).String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("could not create request: %w", err)
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		return "", fmt.Errorf("could not perform request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return "", newStatusError(res)
+	}
+
+	offset := struct {
+		ID string `json:"id"`
+	}{}
+
+	err = json.NewDecoder(res.Body).Decode(&offset)
+	if err != nil {
+		return "", fmt.Errorf("could not decode response: %w", err)
+	}
+
+	return offset.ID, nil
+}
+
+// PollAndCommit polls for the next batch of events of the consumer group,
+// unmarshals them into evts and calls handler with their IDs.
+// The ID of the last event is committed only if the handler returns no error.
+func (c *Client) PollAndCommit(ctx context.Context, group string, limit int, evts any, handler func(ids []string) error) error {
+	ids, err := c.PollForGroupEvents(ctx, group, limit, evts)
+	if err != nil {
+		return err
+	}
+
+	err = handler(ids)
+	if err != nil {
+		return err
+	}
+
+	return c.CommitOffset(ctx, group, ids[len(ids)-1])
+}
+
+// GroupOffset is the last event ID committed by a consumer group.
+type GroupOffset struct {
+	Group string `json:"group"`
+	ID    string `json:"id"`
+}
+
+// GroupOffsets returns the committed offsets of all consumer groups of the topic.
+func (c *Client) GroupOffsets(ctx context.Context) ([]GroupOffset, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.topicURL.JoinPath("groups").String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not perform request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, newStatusError(res)
+	}
+
+	offsets := []GroupOffset{}
+	err = json.NewDecoder(res.Body).Decode(&offsets)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode response: %w", err)
+	}
+
+	return offsets, nil
+}
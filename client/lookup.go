@@ -0,0 +1,90 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrNotFound is returned when the requested event or its topic doesn't exist.
+var ErrNotFound = errors.New("not found")
+
+// GetEvent returns the event with the given ID and its metadata.
+func (c *Client) GetEvent(ctx context.Context, id string) (Event, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.eventsURL.JoinPath(id).String(), nil)
+	if err != nil {
+		return Event{}, fmt.Errorf("could not create request: %w", err)
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		return Event{}, fmt.Errorf("could not perform request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return Event{}, fmt.Errorf("%w: %s", ErrNotFound, newStatusError(res).Body)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return Event{}, newStatusError(res)
+	}
+
+	e := Event{}
+	err = json.NewDecoder(res.Body).Decode(&e)
+	if err != nil {
+		return Event{}, fmt.Errorf("could not decode response: %w", err)
+	}
+
+	return e, nil
+}
+
+// GetEvents returns the events with the given IDs in the order of the IDs,
+// together with the IDs of events which don't exist.
+func (c *Client) GetEvents(ctx context.Context, ids []string) ([]Event, []string, error) {
+	d, err := json.Marshal(struct {
+		IDs []string `json:"ids"`
+	}{IDs: ids})
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.eventsURL.JoinPath("lookup").String(), bytes.NewReader(d))
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not create request: %w", err)
+	}
+
+	req.Header.Set("content-type", "application/json")
+
+	// lookups only read events
+	res, err := c.doIdempotent(req, true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not perform request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil, fmt.Errorf("%w: %s", ErrNotFound, newStatusError(res).Body)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, nil, newStatusError(res)
+	}
+
+	resp := struct {
+		Events  []Event  `json:"events"`
+		Missing []string `json:"missing"`
+	}{}
+
+	err = json.NewDecoder(res.Body).Decode(&resp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not decode response: %w", err)
+	}
+
+	return resp.Events, resp.Missing, nil
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"net/http"
+	"time"
+)
+
+// Option configures optional behaviour of the Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client performing the requests.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithRetryPolicy sets how requests failing with transient errors are retried.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = p
+	}
+}
+
+// WithCircuitBreaker fails requests with ErrCircuitOpen for the open duration
+// after threshold consecutive attempts failed with transient errors.
+func WithCircuitBreaker(threshold int, openDuration time.Duration) Option {
+	return func(c *Client) {
+		c.breaker = newCircuitBreaker(threshold, openDuration)
+	}
+}
+
+// WithToken authenticates requests with the API key or token.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
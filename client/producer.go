@@ -0,0 +1,271 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultProducerBatchSize      = 100
+	defaultProducerLinger         = 10 * time.Millisecond
+	defaultProducerBufferedEvents = 10000
+)
+
+// ErrProducerClosed is returned when sending events to a closed Producer.
+var ErrProducerClosed = errors.New("producer is closed")
+
+type producerConfig struct {
+	batchSize      int
+	linger         time.Duration
+	bufferedEvents int
+}
+
+// ProducerOption configures a Producer.
+type ProducerOption func(*producerConfig)
+
+// WithProducerBatchSize sets the maximal number of events published in one request.
+func WithProducerBatchSize(size int) ProducerOption {
+	return func(c *producerConfig) {
+		c.batchSize = size
+	}
+}
+
+// WithLinger sets how long the first event of a batch waits for more events before the batch is published.
+func WithLinger(linger time.Duration) ProducerOption {
+	return func(c *producerConfig) {
+		c.linger = linger
+	}
+}
+
+// WithBufferedEvents sets the maximal number of events waiting to be published.
+// Sending blocks while the buffer is full.
+func WithBufferedEvents(n int) ProducerOption {
+	return func(c *producerConfig) {
+		c.bufferedEvents = n
+	}
+}
+
+// Delivery is the future result of publishing an event with a Producer.
+type Delivery struct {
+	event     BatchEvent
+	done      chan struct{}
+	published PublishedEvent
+	err       error
+}
+
+// Done is closed when the event has been published or publishing has failed.
+func (d *Delivery) Done() <-chan struct{} {
+	return d.done
+}
+
+// Result returns the published event or the error publishing failed with.
+// It must be called only after Done has been closed.
+func (d *Delivery) Result() (PublishedEvent, error) {
+	return d.published, d.err
+}
+
+// Wait waits until the event has been published or publishing has failed.
+func (d *Delivery) Wait(ctx context.Context) (PublishedEvent, error) {
+	select {
+	case <-d.done:
+		return d.Result()
+	case <-ctx.Done():
+		return PublishedEvent{}, ctx.Err()
+	}
+}
+
+func (d *Delivery) resolve(published PublishedEvent, err error) {
+	d.published = published
+	d.err = err
+	close(d.done)
+}
+
+// producerItem is either an event to publish or a request to flush the pending events.
+type producerItem struct {
+	delivery *Delivery
+	flushed  chan struct{}
+}
+
+// Producer publishes events asynchronously in batches.
+// Events are published when the batch is full or when its first event has waited for the linger time.
+type Producer struct {
+	c      *Client
+	cfg    producerConfig
+	queue  chan producerItem
+	done   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewProducer starts a producer publishing events of the client's topic.
+// The producer must be closed to publish the pending events and release its resources.
+func (c *Client) NewProducer(opts ...ProducerOption) *Producer {
+	cfg := producerConfig{
+		batchSize:      defaultProducerBatchSize,
+		linger:         defaultProducerLinger,
+		bufferedEvents: defaultProducerBufferedEvents,
+	}
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p := &Producer{
+		c:      c,
+		cfg:    cfg,
+		queue:  make(chan producerItem, cfg.bufferedEvents),
+		done:   make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	go p.run()
+
+	return p
+}
+
+// Send queues the payload for publishing.
+// It blocks while the buffer of the producer is full, until the context is done.
+func (p *Producer) Send(ctx context.Context, payload any) (*Delivery, error) {
+	return p.SendEvent(ctx, BatchEvent{Payload: payload})
+}
+
+// SendEvent queues the event with its metadata for publishing.
+// It blocks while the buffer of the producer is full, until the context is done.
+func (p *Producer) SendEvent(ctx context.Context, e BatchEvent) (*Delivery, error) {
+	d := &Delivery{event: e, done: make(chan struct{})}
+	err := p.enqueue(ctx, producerItem{delivery: d})
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Flush publishes all events sent before the call and waits until they are published.
+func (p *Producer) Flush(ctx context.Context) error {
+	flushed := make(chan struct{})
+	err := p.enqueue(ctx, producerItem{flushed: flushed})
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Producer) enqueue(ctx context.Context, item producerItem) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return ErrProducerClosed
+	}
+
+	select {
+	case p.queue <- item:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close publishes the pending events and stops the producer.
+// If the context is done before the pending events are published, their deliveries fail.
+func (p *Producer) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		<-p.done
+		return ctx.Err()
+	}
+}
+
+func (p *Producer) run() {
+	defer close(p.done)
+	defer p.cancel()
+
+	batch := []*Delivery{}
+	var linger *time.Timer
+	var lingerC <-chan time.Time
+
+	flush := func() {
+		if linger != nil {
+			linger.Stop()
+			linger, lingerC = nil, nil
+		}
+		if len(batch) > 0 {
+			p.publish(batch)
+			batch = []*Delivery{}
+		}
+	}
+
+	for {
+		select {
+		case item, ok := <-p.queue:
+			if !ok {
+				flush()
+				return
+			}
+
+			if item.flushed != nil {
+				flush()
+				close(item.flushed)
+				continue
+			}
+
+			batch = append(batch, item.delivery)
+			if len(batch) >= p.cfg.batchSize {
+				flush()
+				continue
+			}
+
+			if lingerC == nil {
+				linger = time.NewTimer(p.cfg.linger)
+				lingerC = linger.C
+			}
+		case <-lingerC:
+			linger, lingerC = nil, nil
+			flush()
+		}
+	}
+}
+
+func (p *Producer) publish(batch []*Delivery) {
+	events := make([]BatchEvent, len(batch))
+	for i, d := range batch {
+		events[i] = d.event
+	}
+
+	published, err := p.c.SendBatch(p.ctx, Batch{Events: events})
+	if err == nil && len(published) != len(batch) {
+		err = fmt.Errorf("server returned %d IDs for %d events", len(published), len(batch))
+	}
+
+	for i, d := range batch {
+		if err != nil {
+			d.resolve(PublishedEvent{}, err)
+			continue
+		}
+		d.resolve(published[i], nil)
+	}
+}
@@ -0,0 +1,196 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RetryPolicy controls how requests failing with transient errors are retried.
+// Only idempotent requests are retried: reads, and publishes carrying an idempotency key.
+type RetryPolicy struct {
+	// MaxAttempts is the maximal number of attempts of a request, 1 disables retries.
+	MaxAttempts int
+	// RetryPublishes sends every publish without an idempotency key with a generated one, so it can be retried.
+	// The server stores each key for its idempotency window.
+	RetryPublishes bool
+	// MinDelay and MaxDelay bound the exponential backoff between attempts.
+	MinDelay time.Duration
+	MaxDelay time.Duration
+	// RetryableStatusCodes are the response status codes retried.
+	// Requests failing before a response is received are always retried.
+	RetryableStatusCodes []int
+}
+
+// DefaultRetryPolicy is used by clients without the WithRetryPolicy option.
+// It retries publishes only when they carry an idempotency key, see RetryPublishes.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinDelay:    100 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+	RetryableStatusCodes: []int{
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	},
+}
+
+func (p RetryPolicy) retryableStatus(code int) bool {
+	for _, c := range p.RetryableStatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrCircuitOpen is returned without performing the request
+// while the circuit breaker is open after repeated failures.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// circuitBreaker fails requests fast after a number of consecutive failures.
+// After the open duration it lets one request through,
+// closing the circuit if it succeeds and opening it again otherwise.
+type circuitBreaker struct {
+	threshold    int
+	openDuration time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func newCircuitBreaker(threshold int, openDuration time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, openDuration: openDuration}
+}
+
+// allow returns false while the circuit is open.
+// It returns probe true for the request probing a half-open circuit, which must call endProbe when done.
+func (b *circuitBreaker) allow() (allowed bool, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true, false
+	}
+
+	if b.probing || time.Now().Before(b.openUntil) {
+		return false, false
+	}
+
+	b.probing = true
+	return true, true
+}
+
+// endProbe lets the next request probe the circuit unless the probe closed it.
+// Probes cancelled before their outcome was recorded don't keep the circuit open.
+func (b *circuitBreaker) endProbe() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+func (b *circuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.openDuration)
+	}
+}
+
+// do performs the request. GET requests and requests with an idempotency key header
+// failing with transient errors are retried according to the retry policy.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	return c.doIdempotent(req, req.Method == http.MethodGet || req.Header.Get("idempotency-key") != "")
+}
+
+// doIdempotent performs the request, retrying it if it is idempotent.
+// Requests that are not idempotent are attempted once, they might have been processed although they failed.
+func (c *Client) doIdempotent(req *http.Request, idempotent bool) (*http.Response, error) {
+	bo := newBackoff(c.retry.MinDelay, c.retry.MaxDelay)
+
+	for attempt := 1; ; attempt++ {
+		res, err := c.attempt(req)
+
+		if !idempotent || attempt >= c.retry.MaxAttempts || !c.retryable(req, res, err) {
+			return res, err
+		}
+
+		if res != nil {
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		err = sleep(req.Context(), bo.next())
+		if err != nil {
+			return nil, err
+		}
+
+		req, err = rewind(req)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *Client) attempt(req *http.Request) (*http.Response, error) {
+	if c.breaker != nil {
+		allowed, probe := c.breaker.allow()
+		if !allowed {
+			return nil, ErrCircuitOpen
+		}
+		if probe {
+			defer c.breaker.endProbe()
+		}
+	}
+
+	if c.token != "" {
+		req.Header.Set("authorization", "Bearer "+c.token)
+	}
+
+	res, err := c.httpClient.Do(req)
+
+	if c.breaker != nil && req.Context().Err() == nil {
+		c.breaker.record(c.retryable(req, res, err))
+	}
+
+	return res, err
+}
+
+// retryable returns true if the attempt failed with a transient error.
+func (c *Client) retryable(req *http.Request, res *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	return c.retry.retryableStatus(res.StatusCode)
+}
+
+// rewind returns a copy of the request with the body reset for another attempt.
+func rewind(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.GetBody == nil {
+		return r, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = body
+	return r, nil
+}
@@ -0,0 +1,170 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Compatibility modes checked when a new version of a schema is registered.
+const (
+	// CompatibilityNone registers new versions without checks.
+	CompatibilityNone = "none"
+	// CompatibilityBackward requires that events valid against the previous version are valid against the new one.
+	CompatibilityBackward = "backward"
+	// CompatibilityForward requires that events valid against the new version are valid against the previous one.
+	CompatibilityForward = "forward"
+	// CompatibilityFull requires both backward and forward compatibility.
+	CompatibilityFull = "full"
+)
+
+// EventTypeHeader is the event header selecting the schema an event is validated against.
+const EventTypeHeader = "type"
+
+// SchemaRegistration registers a new version of a JSON schema.
+type SchemaRegistration struct {
+	// Type is the event type the schema applies to, empty for events without a type specific schema.
+	Type string `json:"type,omitempty"`
+	// Schema is marshalled as JSON, json.RawMessage can be used for schemas that are already encoded.
+	Schema any `json:"schema"`
+	// Compatibility defaults to the compatibility of the previous version, or CompatibilityNone for the first version.
+	Compatibility string `json:"compatibility,omitempty"`
+}
+
+// Schema is a registered version of a JSON schema.
+type Schema struct {
+	Topic         string          `json:"topic"`
+	Type          string          `json:"type,omitempty"`
+	Version       int             `json:"version"`
+	Compatibility string          `json:"compatibility"`
+	Created       time.Time       `json:"created"`
+	Schema        json.RawMessage `json:"schema"`
+}
+
+// SchemaViolation describes why a published event does not match its schema.
+type SchemaViolation struct {
+	// Index is the position of the event in the published batch.
+	Index   int           `json:"index"`
+	Type    string        `json:"type,omitempty"`
+	Version int           `json:"version"`
+	Errors  []SchemaError `json:"errors"`
+}
+
+type SchemaError struct {
+	// InstanceLocation is the JSON pointer of the invalid value within the payload.
+	InstanceLocation string `json:"instanceLocation"`
+	// KeywordLocation is the JSON pointer of the failed keyword within the schema.
+	KeywordLocation string `json:"keywordLocation"`
+	Message         string `json:"message"`
+}
+
+// SchemaViolations returns the events rejected by their schemas if publishing failed with err because of them.
+func SchemaViolations(err error) []SchemaViolation {
+	se := &StatusError{}
+	if !errors.As(err, &se) || se.StatusCode != http.StatusBadRequest {
+		return nil
+	}
+	resp := struct {
+		Violations []SchemaViolation `json:"violations"`
+	}{}
+	if json.Unmarshal([]byte(se.Body), &resp) != nil {
+		return nil
+	}
+	return resp.Violations
+}
+
+// RegisterSchema registers a new version of the schema of the topic or event type.
+// Registering the latest version again returns it without creating a new version.
+// It requires the admin scope.
+func (c *Client) RegisterSchema(ctx context.Context, reg SchemaRegistration) (Schema, error) {
+	sch := Schema{}
+
+	d, err := json.Marshal(reg)
+	if err != nil {
+		return sch, fmt.Errorf("could not marshal schema: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.topicURL.JoinPath("schemas").String(), bytes.NewReader(d))
+	if err != nil {
+		return sch, fmt.Errorf("could not create request: %w", err)
+	}
+
+	req.Header.Set("content-type", "application/json")
+
+	res, err := c.do(req)
+	if err != nil {
+		return sch, fmt.Errorf("could not perform request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		return sch, newStatusError(res)
+	}
+
+	err = json.NewDecoder(res.Body).Decode(&sch)
+	if err != nil {
+		return sch, fmt.Errorf("could not decode response: %w", err)
+	}
+
+	return sch, nil
+}
+
+// Schemas returns all versions of the schemas of the topic, ordered by type and version.
+func (c *Client) Schemas(ctx context.Context) ([]Schema, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.topicURL.JoinPath("schemas").String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not perform request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, newStatusError(res)
+	}
+
+	schemas := []Schema{}
+	err = json.NewDecoder(res.Body).Decode(&schemas)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode response: %w", err)
+	}
+
+	return schemas, nil
+}
+
+// DeleteSchema deletes all versions of the schema of the event type, or of the topic if the type is empty.
+// It requires the admin scope.
+func (c *Client) DeleteSchema(ctx context.Context, eventType string) error {
+	u := c.topicURL.JoinPath("schemas")
+	if eventType != "" {
+		u.RawQuery = "type=" + url.QueryEscape(eventType)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("could not perform request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return newStatusError(res)
+	}
+
+	return nil
+}
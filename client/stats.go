@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// TopicStats describes the events and consumer groups of a topic.
+type TopicStats struct {
+	Topic  string `json:"topic"`
+	Events int    `json:"events"`
+	Bytes  int64  `json:"bytes"`
+	// Oldest and Newest are nil if the topic is empty.
+	Oldest *EventStats  `json:"oldest,omitempty"`
+	Newest *EventStats  `json:"newest,omitempty"`
+	Groups []GroupStats `json:"groups"`
+}
+
+type EventStats struct {
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+}
+
+type GroupStats struct {
+	Group string `json:"group"`
+	ID    string `json:"id"`
+	// Lag is the number of events stored after the committed event.
+	Lag int `json:"lag"`
+}
+
+// Stats returns the statistics of all topics the client may read.
+func (c *Client) Stats(ctx context.Context) ([]TopicStats, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL.JoinPath("stats").String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not perform request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, newStatusError(res)
+	}
+
+	stats := []TopicStats{}
+	err = json.NewDecoder(res.Body).Decode(&stats)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode response: %w", err)
+	}
+
+	return stats, nil
+}
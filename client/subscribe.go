@@ -0,0 +1,194 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultSubscribeBatchSize = 100
+	defaultMinRetryDelay      = 100 * time.Millisecond
+	defaultMaxRetryDelay      = 10 * time.Second
+)
+
+type subscribeConfig struct {
+	batchSize     int
+	filters       []string
+	minRetryDelay time.Duration
+	maxRetryDelay time.Duration
+	onError       func(err error)
+	until         time.Time
+}
+
+// SubscribeOption configures a subscription.
+type SubscribeOption func(*subscribeConfig)
+
+// WithBatchSize sets the maximal number of events delivered to the handler at once.
+func WithBatchSize(size int) SubscribeOption {
+	return func(c *subscribeConfig) {
+		c.batchSize = size
+	}
+}
+
+// WithFilters delivers only events matching all filters, see PollFiltered.
+func WithFilters(filters ...string) SubscribeOption {
+	return func(c *subscribeConfig) {
+		c.filters = filters
+	}
+}
+
+// WithRetryDelay sets the bounds of the exponential backoff between retries of failed polls.
+func WithRetryDelay(min, max time.Duration) SubscribeOption {
+	return func(c *subscribeConfig) {
+		c.minRetryDelay = min
+		c.maxRetryDelay = max
+	}
+}
+
+// WithErrorHandler is called with each transient error before the poll is retried.
+func WithErrorHandler(onError func(err error)) SubscribeOption {
+	return func(c *subscribeConfig) {
+		c.onError = onError
+	}
+}
+
+// WithUntil ends the subscription after delivering the events created before the time.
+func WithUntil(until time.Time) SubscribeOption {
+	return func(c *subscribeConfig) {
+		c.until = until
+	}
+}
+
+// isTransient returns true if a failed request may succeed when retried later.
+func (c *Client) isTransient(err error) bool {
+	se := &StatusError{}
+	if errors.As(err, &se) {
+		return c.retry.retryableStatus(se.StatusCode)
+	}
+	// the request could not be performed, e.g. the server is not reachable or the circuit is open
+	return true
+}
+
+// Subscribe delivers batches of events published after the event with the ID from to the handler,
+// until the context is done or the handler returns an error.
+// An empty from starts with the oldest event.
+// Each batch starts after the last event of the previous batch.
+// Transient failures are retried with exponential backoff,
+// other failures and errors returned by the handler end the subscription.
+func (c *Client) Subscribe(ctx context.Context, from string, handler func(ctx context.Context, events []Event) error, opts ...SubscribeOption) error {
+	return c.subscribe(ctx, from, time.Time{}, handler, opts)
+}
+
+// SubscribeSince is like Subscribe, but starts with the first event created at or after the time.
+// Combined with WithUntil it replays the events of a time range.
+func (c *Client) SubscribeSince(ctx context.Context, since time.Time, handler func(ctx context.Context, events []Event) error, opts ...SubscribeOption) error {
+	return c.subscribe(ctx, "", since, handler, opts)
+}
+
+func (c *Client) subscribe(ctx context.Context, from string, since time.Time, handler func(ctx context.Context, events []Event) error, opts []SubscribeOption) error {
+	cfg := &subscribeConfig{
+		batchSize:     defaultSubscribeBatchSize,
+		minRetryDelay: defaultMinRetryDelay,
+		maxRetryDelay: defaultMaxRetryDelay,
+	}
+	for _, o := range opts {
+		o(cfg)
+	}
+
+	q := url.Values{}
+	q.Set("limit", strconv.Itoa(cfg.batchSize))
+	if len(cfg.filters) > 0 {
+		q["filter"] = cfg.filters
+	}
+	if !cfg.until.IsZero() {
+		q.Set("until", cfg.until.Format(time.RFC3339Nano))
+	}
+
+	cursor := from
+	bo := newBackoff(cfg.minRetryDelay, cfg.maxRetryDelay)
+
+	for {
+		if cursor == "" && !since.IsZero() {
+			// the server responds with a cursor positioned at the time, subsequent polls continue from it
+			q.Set("since", since.Format(time.RFC3339Nano))
+			q.Del("after")
+		} else {
+			q.Set("after", cursor)
+			q.Del("since")
+		}
+
+		events, next, err := c.pollForEvents(ctx, q)
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err == errTimeout {
+			if next != "" {
+				cursor = next
+			}
+			continue
+		}
+
+		if err != nil {
+			if !c.isTransient(err) {
+				return err
+			}
+			if cfg.onError != nil {
+				cfg.onError(err)
+			}
+			err = sleep(ctx, bo.next())
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		bo.reset()
+
+		if len(events) == 0 && (next == "" || next == cursor) {
+			// the server responds without waiting only when there are no more events before until
+			return nil
+		}
+
+		if len(events) > 0 {
+			err = handler(ctx, events)
+			if err != nil {
+				return err
+			}
+			cursor = events[len(events)-1].ID
+		}
+
+		// with filters the server may have skipped events after the last delivered one
+		if next != "" {
+			cursor = next
+		}
+	}
+}
+
+// TypedEvent is an event with the payload unmarshalled into T.
+type TypedEvent[T any] struct {
+	Event
+	Data T
+}
+
+// SubscribeTyped is like Client.Subscribe, but delivers events with payloads unmarshalled into T.
+// A payload that can't be unmarshalled ends the subscription.
+func SubscribeTyped[T any](ctx context.Context, c *Client, from string, handler func(ctx context.Context, events []TypedEvent[T]) error, opts ...SubscribeOption) error {
+	return c.Subscribe(ctx, from, func(ctx context.Context, events []Event) error {
+		typed := make([]TypedEvent[T], len(events))
+		for i, e := range events {
+			typed[i].Event = e
+			err := json.Unmarshal(e.Payload, &typed[i].Data)
+			if err != nil {
+				return fmt.Errorf("could not unmarshal payload of event %s: %w", e.ID, err)
+			}
+		}
+		return handler(ctx, typed)
+	}, opts...)
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// TopicConfig configures a topic. Unset fields are left unchanged.
+type TopicConfig struct {
+	// Compaction enables key-based compaction: the pruner keeps only the latest event
+	// of each key and deletes keys with tombstones. Unset topics follow the server default.
+	Compaction *bool `json:"compaction,omitempty"`
+}
+
+// TopicInfo is the effective configuration of a topic.
+type TopicInfo struct {
+	Topic      string `json:"topic"`
+	Compaction bool   `json:"compaction"`
+}
+
+// CreateTopic creates the named topic. Creating an existing topic is not an error.
+func (c *Client) CreateTopic(ctx context.Context, name string) error {
+	req, err := http.NewRequestWithContext(ctx, "PUT", c.baseURL.JoinPath("topics", name).String(), nil)
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("could not perform request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		return newStatusError(res)
+	}
+
+	return nil
+}
+
+// ConfigureTopic creates the named topic if it doesn't exist and updates its configuration.
+func (c *Client) ConfigureTopic(ctx context.Context, name string, cfg TopicConfig) error {
+	d, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("could not marshal topic configuration: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", c.baseURL.JoinPath("topics", name).String(), bytes.NewReader(d))
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+
+	req.Header.Set("content-type", "application/json")
+
+	res, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("could not perform request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		return newStatusError(res)
+	}
+
+	return nil
+}
+
+// GetTopic returns the effective configuration of the named topic.
+func (c *Client) GetTopic(ctx context.Context, name string) (TopicInfo, error) {
+	info := TopicInfo{}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL.JoinPath("topics", name).String(), nil)
+	if err != nil {
+		return info, fmt.Errorf("could not create request: %w", err)
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		return info, fmt.Errorf("could not perform request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return info, newStatusError(res)
+	}
+
+	err = json.NewDecoder(res.Body).Decode(&info)
+	if err != nil {
+		return info, fmt.Errorf("could not decode response: %w", err)
+	}
+
+	return info, nil
+}
+
+// DeleteTopic deletes the named topic together with all of its events.
+func (c *Client) DeleteTopic(ctx context.Context, name string) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", c.baseURL.JoinPath("topics", name).String(), nil)
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("could not perform request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return newStatusError(res)
+	}
+
+	return nil
+}
+
+// ListTopics returns the names of all topics, including the default topic.
+func (c *Client) ListTopics(ctx context.Context) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL.JoinPath("topics").String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not perform request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, newStatusError(res)
+	}
+
+	names := []string{}
+	err = json.NewDecoder(res.Body).Decode(&names)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode response: %w", err)
+	}
+
+	return names, nil
+}
@@ -0,0 +1,219 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v3.21.12
+// source: eventbuffer.proto
+
+package eventbufferpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	EventBuffer_Publish_FullMethodName   = "/eventbuffer.v1.EventBuffer/Publish"
+	EventBuffer_Poll_FullMethodName      = "/eventbuffer.v1.EventBuffer/Poll"
+	EventBuffer_Subscribe_FullMethodName = "/eventbuffer.v1.EventBuffer/Subscribe"
+)
+
+// EventBufferClient is the client API for EventBuffer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type EventBufferClient interface {
+	// Publish appends a batch of events to a topic.
+	Publish(ctx context.Context, in *PublishRequest, opts ...grpc.CallOption) (*PublishResponse, error)
+	// Poll returns the next batch of events, waiting for new events if there are none.
+	// If no events are published before the poll times out, an empty batch is returned.
+	Poll(ctx context.Context, in *PollRequest, opts ...grpc.CallOption) (*PollResponse, error)
+	// Subscribe streams batches of events as they are published.
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (EventBuffer_SubscribeClient, error)
+}
+
+type eventBufferClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEventBufferClient(cc grpc.ClientConnInterface) EventBufferClient {
+	return &eventBufferClient{cc}
+}
+
+func (c *eventBufferClient) Publish(ctx context.Context, in *PublishRequest, opts ...grpc.CallOption) (*PublishResponse, error) {
+	out := new(PublishResponse)
+	err := c.cc.Invoke(ctx, EventBuffer_Publish_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventBufferClient) Poll(ctx context.Context, in *PollRequest, opts ...grpc.CallOption) (*PollResponse, error) {
+	out := new(PollResponse)
+	err := c.cc.Invoke(ctx, EventBuffer_Poll_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventBufferClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (EventBuffer_SubscribeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &EventBuffer_ServiceDesc.Streams[0], EventBuffer_Subscribe_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &eventBufferSubscribeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type EventBuffer_SubscribeClient interface {
+	Recv() (*SubscribeResponse, error)
+	grpc.ClientStream
+}
+
+type eventBufferSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *eventBufferSubscribeClient) Recv() (*SubscribeResponse, error) {
+	m := new(SubscribeResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// EventBufferServer is the server API for EventBuffer service.
+// All implementations must embed UnimplementedEventBufferServer
+// for forward compatibility
+type EventBufferServer interface {
+	// Publish appends a batch of events to a topic.
+	Publish(context.Context, *PublishRequest) (*PublishResponse, error)
+	// Poll returns the next batch of events, waiting for new events if there are none.
+	// If no events are published before the poll times out, an empty batch is returned.
+	Poll(context.Context, *PollRequest) (*PollResponse, error)
+	// Subscribe streams batches of events as they are published.
+	Subscribe(*SubscribeRequest, EventBuffer_SubscribeServer) error
+	mustEmbedUnimplementedEventBufferServer()
+}
+
+// UnimplementedEventBufferServer must be embedded to have forward compatible implementations.
+type UnimplementedEventBufferServer struct {
+}
+
+func (UnimplementedEventBufferServer) Publish(context.Context, *PublishRequest) (*PublishResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Publish not implemented")
+}
+func (UnimplementedEventBufferServer) Poll(context.Context, *PollRequest) (*PollResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Poll not implemented")
+}
+func (UnimplementedEventBufferServer) Subscribe(*SubscribeRequest, EventBuffer_SubscribeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedEventBufferServer) mustEmbedUnimplementedEventBufferServer() {}
+
+// UnsafeEventBufferServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EventBufferServer will
+// result in compilation errors.
+type UnsafeEventBufferServer interface {
+	mustEmbedUnimplementedEventBufferServer()
+}
+
+func RegisterEventBufferServer(s grpc.ServiceRegistrar, srv EventBufferServer) {
+	s.RegisterService(&EventBuffer_ServiceDesc, srv)
+}
+
+func _EventBuffer_Publish_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PublishRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventBufferServer).Publish(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventBuffer_Publish_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventBufferServer).Publish(ctx, req.(*PublishRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventBuffer_Poll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PollRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventBufferServer).Poll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventBuffer_Poll_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventBufferServer).Poll(ctx, req.(*PollRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventBuffer_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EventBufferServer).Subscribe(m, &eventBufferSubscribeServer{stream})
+}
+
+type EventBuffer_SubscribeServer interface {
+	Send(*SubscribeResponse) error
+	grpc.ServerStream
+}
+
+type eventBufferSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *eventBufferSubscribeServer) Send(m *SubscribeResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// EventBuffer_ServiceDesc is the grpc.ServiceDesc for EventBuffer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var EventBuffer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "eventbuffer.v1.EventBuffer",
+	HandlerType: (*EventBufferServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Publish",
+			Handler:    _EventBuffer_Publish_Handler,
+		},
+		{
+			MethodName: "Poll",
+			Handler:    _EventBuffer_Poll_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _EventBuffer_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "eventbuffer.proto",
+}
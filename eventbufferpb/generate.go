@@ -0,0 +1,5 @@
+// Package eventbufferpb contains the gRPC API of the event buffer
+// and the Go client generated from it.
+package eventbufferpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative eventbuffer.proto
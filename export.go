@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/draganm/bolted/embedded"
+	"github.com/draganm/event-buffer/client"
+	"github.com/draganm/event-buffer/server"
+	"github.com/go-logr/logr"
+	"github.com/urfave/cli/v2"
+	"go.etcd.io/bbolt"
+)
+
+// exportBatchSize is the number of events read or sent per request to a running server.
+const exportBatchSize = 1000
+
+// sourceFlags select a stopped or a running server, connected to like clientFlags but without a default --server.
+var sourceFlags = append([]cli.Flag{
+	&cli.StringFlag{
+		Name:  "state-file",
+		Usage: "state file of a stopped server",
+	},
+	&cli.StringFlag{
+		Name:  "server",
+		Usage: "base URL of a running server",
+	},
+	tokenFlag,
+}, clientTLSFlags...)
+
+func checkSource(c *cli.Context) error {
+	if (c.String("state-file") == "") == (c.String("server") == "") {
+		return errors.New("exactly one of --state-file and --server is required")
+	}
+	return nil
+}
+
+func parseTimeFlag(c *cli.Context, name string) (time.Time, error) {
+	if c.String(name) == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, c.String(name))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not parse --%s: %w", name, err)
+	}
+	return t, nil
+}
+
+var exportCommand = &cli.Command{
+	Name:  "export",
+	Usage: "write events as newline delimited JSON",
+	Flags: append([]cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "topic",
+			Usage: "topic to export, all topics if not set",
+		},
+		&cli.StringFlag{
+			Name:  "after-id",
+			Usage: "export events after the event with the ID",
+		},
+		&cli.StringFlag{
+			Name:  "until-id",
+			Usage: "export events up to and including the event with the ID",
+		},
+		&cli.StringFlag{
+			Name:  "since",
+			Usage: "export events created at or after the RFC3339 time",
+		},
+		&cli.StringFlag{
+			Name:  "until",
+			Usage: "export events created before the RFC3339 time",
+		},
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "file to write, stdout if not set",
+		},
+	}, sourceFlags...),
+	Action: func(c *cli.Context) error {
+		err := checkSource(c)
+		if err != nil {
+			return err
+		}
+
+		r := server.EventRange{
+			AfterID: c.String("after-id"),
+			UntilID: c.String("until-id"),
+		}
+
+		r.Since, err = parseTimeFlag(c, "since")
+		if err != nil {
+			return err
+		}
+
+		r.Until, err = parseTimeFlag(c, "until")
+		if err != nil {
+			return err
+		}
+
+		var out io.Writer = os.Stdout
+		if c.String("output") != "" {
+			f, err := os.Create(c.String("output"))
+			if err != nil {
+				return fmt.Errorf("could not create output: %w", err)
+			}
+			defer f.Close()
+			out = f
+		}
+
+		bw := bufio.NewWriter(out)
+		enc := json.NewEncoder(bw)
+
+		write := func(e server.ExportedEvent) error {
+			return enc.Encode(e)
+		}
+
+		if c.String("state-file") != "" {
+			err = exportStateFile(c.String("state-file"), c.StringSlice("topic"), r, write)
+		} else {
+			err = exportServer(c, c.StringSlice("topic"), r, write)
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not export events: %w", err)
+		}
+
+		return bw.Flush()
+	},
+}
+
+func exportStateFile(stateFile string, topics []string, r server.EventRange, fn func(server.ExportedEvent) error) error {
+	// a running server holds the lock of the state file
+	db, err := embedded.Open(stateFile, 0700, embedded.Options{Options: bbolt.Options{ReadOnly: true, Timeout: time.Second}})
+	if err != nil {
+		return fmt.Errorf("could not open state, is the server still running?: %w", err)
+	}
+	defer db.Close()
+
+	return server.ExportEvents(db, topics, r, fn)
+}
+
+func exportServer(c *cli.Context, topics []string, r server.EventRange, fn func(server.ExportedEvent) error) error {
+	cl, err := serverClient(c)
+	if err != nil {
+		return err
+	}
+
+	if len(topics) == 0 {
+		topics, err = cl.ListTopics(c.Context)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, topic := range topics {
+		err = exportTopic(c.Context, cl.Topic(topic), topic, r, fn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func exportTopic(ctx context.Context, cl *client.Client, topic string, r server.EventRange, fn func(server.ExportedEvent) error) error {
+	after := r.AfterID
+	for {
+		events, err := cl.ReadEvents(ctx, after, exportBatchSize)
+		if err != nil {
+			return fmt.Errorf("could not read events of topic %s: %w", topic, err)
+		}
+
+		if len(events) == 0 {
+			return nil
+		}
+
+		for _, e := range events {
+			if r.Past(e.ID, e.Time) {
+				return nil
+			}
+
+			if !r.Contains(e.ID, e.Time) {
+				continue
+			}
+
+			ee := server.ExportedEvent{
+				Topic:       topic,
+				ID:          e.ID,
+				Time:        e.Time,
+				Payload:     e.Payload,
+				Headers:     e.Headers,
+				Key:         e.Key,
+				ContentType: e.ContentType,
+			}
+
+			if !e.ReceivedAt.IsZero() {
+				receivedAt := e.ReceivedAt
+				ee.ReceivedAt = &receivedAt
+			}
+
+			err = fn(ee)
+			if err != nil {
+				return err
+			}
+		}
+
+		after = events[len(events)-1].ID
+	}
+}
+
+var importCommand = &cli.Command{
+	Name:  "import",
+	Usage: "store events read as newline delimited JSON",
+	Description: "Events imported into a state file keep their IDs, which must be newer than the events already stored in their topic.\n" +
+		"Events imported into a running server are assigned new IDs, the original IDs are used as idempotency keys.",
+	ArgsUsage: "[file, - or none for stdin]",
+	Flags:     sourceFlags,
+	Action: func(c *cli.Context) error {
+		err := checkSource(c)
+		if err != nil {
+			return err
+		}
+
+		var in io.Reader = os.Stdin
+		if c.Args().Present() && c.Args().First() != "-" {
+			f, err := os.Open(c.Args().First())
+			if err != nil {
+				return fmt.Errorf("could not open input: %w", err)
+			}
+			defer f.Close()
+			in = f
+		}
+
+		dec := json.NewDecoder(bufio.NewReader(in))
+		line := 0
+		next := func() (server.ExportedEvent, bool, error) {
+			e := server.ExportedEvent{}
+			err := dec.Decode(&e)
+			if err == io.EOF {
+				return e, false, nil
+			}
+			line++
+			if err != nil {
+				return e, false, fmt.Errorf("could not decode event %d: %w", line, err)
+			}
+			if e.Topic == "" {
+				e.Topic = server.DefaultTopic
+			}
+			return e, true, nil
+		}
+
+		var imported int
+		if c.String("state-file") != "" {
+			imported, err = importStateFile(c.String("state-file"), next)
+		} else {
+			imported, err = importServer(c, next)
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not import events: %w", err)
+		}
+
+		fmt.Fprintf(os.Stderr, "imported %d events\n", imported)
+		return nil
+	},
+}
+
+func importStateFile(stateFile string, next func() (server.ExportedEvent, bool, error)) (int, error) {
+	db, err := embedded.Open(stateFile, 0700, embedded.Options{Options: bbolt.Options{Timeout: time.Second}})
+	if err != nil {
+		return 0, fmt.Errorf("could not open state, is the server still running?: %w", err)
+	}
+	defer db.Close()
+
+	srv, err := server.New(logr.Discard(), db)
+	if err != nil {
+		return 0, err
+	}
+
+	return srv.ImportEvents(next)
+}
+
+func importServer(c *cli.Context, next func() (server.ExportedEvent, bool, error)) (int, error) {
+	cl, err := serverClient(c)
+	if err != nil {
+		return 0, err
+	}
+
+	imported := 0
+	topic := ""
+	batch := client.Batch{}
+	created := map[string]bool{server.DefaultTopic: true}
+
+	flush := func() error {
+		if len(batch.Events) == 0 {
+			return nil
+		}
+		if !created[topic] {
+			err := cl.CreateTopic(c.Context, topic)
+			if err != nil {
+				return fmt.Errorf("could not create topic %s: %w", topic, err)
+			}
+			created[topic] = true
+		}
+		_, err := cl.Topic(topic).SendBatch(c.Context, batch)
+		if err != nil {
+			return fmt.Errorf("could not send events to topic %s: %w", topic, err)
+		}
+		imported += len(batch.Events)
+		batch = client.Batch{}
+		return nil
+	}
+
+	for {
+		e, more, err := next()
+		if err != nil {
+			return imported, err
+		}
+
+		if !more {
+			return imported, flush()
+		}
+
+		if e.Topic != topic || len(batch.Events) == exportBatchSize {
+			err = flush()
+			if err != nil {
+				return imported, err
+			}
+			topic = e.Topic
+		}
+
+		batch.Events = append(batch.Events, client.BatchEvent{
+			Payload:        e.Payload,
+			Headers:        e.Headers,
+			Key:            e.Key,
+			ContentType:    e.ContentType,
+			IdempotencyKey: e.ID,
+		})
+	}
+}
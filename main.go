@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"syscall"
@@ -13,6 +15,7 @@ import (
 
 	"github.com/draganm/bolted"
 	"github.com/draganm/bolted/embedded"
+	"github.com/draganm/event-buffer/client"
 	"github.com/draganm/event-buffer/server"
 	"github.com/go-logr/logr"
 	"github.com/go-logr/zapr"
@@ -22,6 +25,8 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 func main() {
@@ -42,12 +47,26 @@ func main() {
 
 	defer logger.Sync()
 	app := &cli.App{
+		Commands: []*cli.Command{
+			tokenCommand,
+			restoreCommand,
+			exportCommand,
+			importCommand,
+			sendCommand,
+			tailCommand,
+			statsCommand,
+		},
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    "addr",
 				Value:   ":5566",
 				EnvVars: []string{"ADDR"},
 			},
+			&cli.StringFlag{
+				Name:    "grpc-addr",
+				Value:   ":5567",
+				EnvVars: []string{"GRPC_ADDR"},
+			},
 			&cli.StringFlag{
 				Name:    "metrics-addr",
 				Value:   ":3000",
@@ -73,6 +92,122 @@ func main() {
 				EnvVars: []string{"PRUNE_FREQUENCY"},
 				Value:   5 * time.Minute,
 			},
+			&cli.Int64Flag{
+				Name:    "max-events",
+				EnvVars: []string{"MAX_EVENTS"},
+				Usage:   "maximal number of events retained per topic, 0 for no limit",
+			},
+			&cli.Int64Flag{
+				Name:    "max-bytes",
+				EnvVars: []string{"MAX_BYTES"},
+				Usage:   "maximal number of bytes retained per topic, 0 for no limit",
+			},
+			&cli.BoolFlag{
+				Name:    "enforce-retention-on-write",
+				EnvVars: []string{"ENFORCE_RETENTION_ON_WRITE"},
+				Usage:   "enforce max-events and max-bytes when events are published, not only when pruning",
+			},
+			&cli.DurationFlag{
+				Name:    "consumer-retention-max-age",
+				EnvVars: []string{"CONSUMER_RETENTION_MAX_AGE"},
+				Usage:   "keep events older than retention-period until all consumer groups committed them, but not longer than this, 0 to disable",
+			},
+			&cli.BoolFlag{
+				Name:    "compaction",
+				EnvVars: []string{"COMPACTION"},
+				Usage:   "keep only the latest event of each key in topics that don't configure compaction themselves, when the buffer is pruned; the latest events are kept regardless of their age, but not beyond the count and byte limits",
+			},
+			&cli.StringFlag{
+				Name:    "api-keys-file",
+				EnvVars: []string{"API_KEYS_FILE"},
+				Usage:   "JSON file with API keys and their grants, enables authentication",
+			},
+			&cli.StringFlag{
+				Name:    "token-secret-file",
+				EnvVars: []string{"TOKEN_SECRET_FILE"},
+				Usage:   "file with the secret verifying HMAC signed tokens, enables authentication",
+			},
+			&cli.StringFlag{
+				Name:    "tls-cert-file",
+				EnvVars: []string{"TLS_CERT_FILE"},
+				Usage:   "certificate file enabling TLS for the API and gRPC listener",
+			},
+			&cli.StringFlag{
+				Name:    "tls-key-file",
+				EnvVars: []string{"TLS_KEY_FILE"},
+				Usage:   "private key file of the API and gRPC listener certificate",
+			},
+			&cli.StringFlag{
+				Name:    "tls-client-ca-file",
+				EnvVars: []string{"TLS_CLIENT_CA_FILE"},
+				Usage:   "CA certificates enabling mutual TLS for the API and gRPC listener",
+			},
+			&cli.StringFlag{
+				Name:    "metrics-tls-cert-file",
+				EnvVars: []string{"METRICS_TLS_CERT_FILE"},
+				Usage:   "certificate file enabling TLS for the metrics listener",
+			},
+			&cli.StringFlag{
+				Name:    "metrics-tls-key-file",
+				EnvVars: []string{"METRICS_TLS_KEY_FILE"},
+				Usage:   "private key file of the metrics listener certificate",
+			},
+			&cli.StringFlag{
+				Name:    "metrics-tls-client-ca-file",
+				EnvVars: []string{"METRICS_TLS_CLIENT_CA_FILE"},
+				Usage:   "CA certificates enabling mutual TLS for the metrics listener",
+			},
+			&cli.StringFlag{
+				Name:    "internal-tls-cert-file",
+				EnvVars: []string{"INTERNAL_TLS_CERT_FILE"},
+				Usage:   "certificate file enabling TLS for the internal listener",
+			},
+			&cli.StringFlag{
+				Name:    "internal-tls-key-file",
+				EnvVars: []string{"INTERNAL_TLS_KEY_FILE"},
+				Usage:   "private key file of the internal listener certificate",
+			},
+			&cli.StringFlag{
+				Name:    "internal-tls-client-ca-file",
+				EnvVars: []string{"INTERNAL_TLS_CLIENT_CA_FILE"},
+				Usage:   "CA certificates enabling mutual TLS for the internal listener",
+			},
+			&cli.StringFlag{
+				Name:    "follow",
+				EnvVars: []string{"FOLLOW"},
+				Usage:   "URL of a leader to replicate, makes this instance a read-only follower; local topics not found on the leader are deleted",
+			},
+			&cli.BoolFlag{
+				Name:    "forward-writes",
+				EnvVars: []string{"FORWARD_WRITES"},
+				Usage:   "forward HTTP writes received by a follower to the leader instead of rejecting them; gRPC and WebSocket publishes are always rejected",
+			},
+			&cli.StringFlag{
+				Name:    "follow-token",
+				EnvVars: []string{"FOLLOW_TOKEN"},
+				Usage:   "token authenticating the follower at the leader, replicating deletions and redactions requires the admin scope",
+			},
+			&cli.StringFlag{
+				Name:    "follow-ca-file",
+				EnvVars: []string{"FOLLOW_CA_FILE"},
+				Usage:   "CA certificates verifying the leader",
+			},
+			&cli.StringFlag{
+				Name:    "follow-cert-file",
+				EnvVars: []string{"FOLLOW_CERT_FILE"},
+				Usage:   "client certificate presented to the leader",
+			},
+			&cli.StringFlag{
+				Name:    "follow-key-file",
+				EnvVars: []string{"FOLLOW_KEY_FILE"},
+				Usage:   "private key of the client certificate presented to the leader",
+			},
+			&cli.DurationFlag{
+				Name:    "idempotency-window",
+				EnvVars: []string{"IDEMPOTENCY_WINDOW"},
+				Value:   server.DefaultIdempotencyWindow,
+				Usage:   "how long idempotency keys of published events are remembered",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			log := zapr.NewLogger(logger)
@@ -84,7 +219,49 @@ func main() {
 				return fmt.Errorf("could not open state: %w", err)
 			}
 
-			srv, err := server.New(log, db)
+			authenticators, err := loadAuthenticators(c)
+			if err != nil {
+				return err
+			}
+
+			if len(authenticators) == 0 {
+				log.Info("authentication is disabled, all requests are permitted")
+			}
+
+			opts := []server.Option{
+				server.WithAuthenticators(authenticators...),
+				server.WithIdempotencyWindow(c.Duration("idempotency-window")),
+				server.WithMaxEvents(c.Int64("max-events")),
+				server.WithMaxBytes(c.Int64("max-bytes")),
+				server.WithRetentionOnWrite(c.Bool("enforce-retention-on-write")),
+				server.WithConsumerAwareRetention(c.Duration("consumer-retention-max-age")),
+				server.WithCompaction(c.Bool("compaction")),
+			}
+
+			var leader *client.Client
+			if c.String("follow") != "" {
+				leaderURL, err := url.Parse(c.String("follow"))
+				if err != nil {
+					return fmt.Errorf("could not parse leader URL: %w", err)
+				}
+
+				leaderTLS, err := leaderTLSConfig(c)
+				if err != nil {
+					return err
+				}
+
+				leader, err = leaderClient(c, leaderTLS)
+				if err != nil {
+					return err
+				}
+
+				opts = append(opts, server.WithLeader(leaderURL, c.Bool("forward-writes")))
+				if leaderTLS != nil {
+					opts = append(opts, server.WithLeaderTLSConfig(leaderTLS))
+				}
+			}
+
+			srv, err := server.New(log, db, opts...)
 			if err != nil {
 				return fmt.Errorf("could not start server: %w", err)
 			}
@@ -108,16 +285,40 @@ func main() {
 
 			// run API server
 
-			eg.Go(runHttp(ctx, log, c.String("addr"), "api", srv))
+			apiTLS, err := listenerTLSConfig(c, log, "")
+			if err != nil {
+				return err
+			}
+
+			metricsTLS, err := listenerTLSConfig(c, log, "metrics-")
+			if err != nil {
+				return err
+			}
+
+			internalTLS, err := listenerTLSConfig(c, log, "internal-")
+			if err != nil {
+				return err
+			}
+
+			eg.Go(runHttp(ctx, log, c.String("addr"), "api", srv, apiTLS))
+
+			// run gRPC API server
+			grpcOptions := []grpc.ServerOption{}
+			if apiTLS != nil {
+				grpcOptions = append(grpcOptions, grpc.Creds(credentials.NewTLS(apiTLS)))
+			}
+			grpcServer := grpc.NewServer(grpcOptions...)
+			srv.RegisterGRPC(grpcServer)
+			eg.Go(runGrpc(ctx, log, c.String("grpc-addr"), grpcServer))
 
 			// run metrics server
 			metricsRouter := mux.NewRouter()
 			metricsRouter.Methods("GET").Path("/metrics").Handler(promhttp.Handler())
-			eg.Go(runHttp(ctx, log, c.String("metrics-addr"), "metrics", metricsRouter))
+			eg.Go(runHttp(ctx, log, c.String("metrics-addr"), "metrics", metricsRouter, metricsTLS))
 
 			// run internal api
 			internalRouter := mux.NewRouter()
-			internalRouter.Methods("GET").Path("/dump").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			internalRouter.Methods("GET").Path("/dump").Handler(srv.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				w.Header().Set("content-type", "application/binary")
 				err := bolted.SugaredRead(db, func(tx bolted.SugaredReadTx) error {
 					tx.Dump(w)
@@ -127,9 +328,26 @@ func main() {
 					http.Error(w, fmt.Errorf("could not write dump: %w", err).Error(), http.StatusInternalServerError)
 					return
 				}
-			})
+			})))
+
+			internalRouter.Methods("GET").Path("/backup").Handler(srv.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("content-type", "application/gzip")
+				w.Header().Set("content-disposition", `attachment; filename="event-buffer-backup.ndjson.gz"`)
+				err := srv.Backup(w)
+				if err != nil {
+					// the response has already started, the restore will fail on the missing trailer
+					log.Error(err, "could not write backup")
+				}
+			})))
 
-			eg.Go(runHttp(ctx, log, c.String("internal-addr"), "internal", internalRouter))
+			eg.Go(runHttp(ctx, log, c.String("internal-addr"), "internal", internalRouter, internalTLS))
+
+			if leader != nil {
+				eg.Go(func() error {
+					log.Info("following leader", "url", c.String("follow"))
+					return srv.Follow(ctx, leader)
+				})
+			}
 
 			// run the pruner
 			eg.Go(func() error {
@@ -156,7 +374,74 @@ func main() {
 	app.RunAndExitOnError()
 }
 
-func runHttp(ctx context.Context, log logr.Logger, addr, name string, handler http.Handler) func() error {
+func loadAuthenticators(c *cli.Context) ([]server.Authenticator, error) {
+	authenticators := []server.Authenticator{}
+
+	if c.String("api-keys-file") != "" {
+		keys, err := server.LoadAPIKeys(c.String("api-keys-file"))
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, keys)
+	}
+
+	if c.String("token-secret-file") != "" {
+		secret, err := readTokenSecret(c.String("token-secret-file"))
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, server.NewHMACTokens(secret))
+	}
+
+	return authenticators, nil
+}
+
+// listenerTLSConfig returns the TLS configuration of the listener with the flag prefix,
+// or nil if TLS is not enabled for the listener.
+func listenerTLSConfig(c *cli.Context, log logr.Logger, prefix string) (*tls.Config, error) {
+	certFile := c.String(prefix + "tls-cert-file")
+	keyFile := c.String(prefix + "tls-key-file")
+	clientCAFile := c.String(prefix + "tls-client-ca-file")
+
+	if certFile == "" && keyFile == "" && clientCAFile == "" {
+		return nil, nil
+	}
+
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("both --%stls-cert-file and --%stls-key-file are required to enable TLS", prefix, prefix)
+	}
+
+	cfg, err := server.NewTLSConfig(log, certFile, keyFile, clientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not configure TLS of --%saddr: %w", prefix, err)
+	}
+
+	return cfg, nil
+}
+
+// leaderTLSConfig returns the TLS configuration of connections to the leader, nil if the defaults are used.
+func leaderTLSConfig(c *cli.Context) (*tls.Config, error) {
+	if c.String("follow-ca-file") == "" && c.String("follow-cert-file") == "" && c.String("follow-key-file") == "" {
+		return nil, nil
+	}
+	return client.LoadTLSConfig(c.String("follow-ca-file"), c.String("follow-cert-file"), c.String("follow-key-file"))
+}
+
+func leaderClient(c *cli.Context, tlsConfig *tls.Config) (*client.Client, error) {
+	opts := []client.Option{}
+
+	if c.String("follow-token") != "" {
+		opts = append(opts, client.WithToken(c.String("follow-token")))
+	}
+
+	if tlsConfig != nil {
+		opts = append(opts, client.WithTLSConfig(tlsConfig))
+	}
+
+	return client.New(c.String("follow"), opts...)
+}
+
+func runHttp(ctx context.Context, log logr.Logger, addr, name string, handler http.Handler, tlsConfig *tls.Config) func() error {
 
 	return func() error {
 		l, err := net.Listen("tcp", addr)
@@ -165,6 +450,10 @@ func runHttp(ctx context.Context, log logr.Logger, addr, name string, handler ht
 
 		}
 
+		if tlsConfig != nil {
+			l = tls.NewListener(l, tlsConfig)
+		}
+
 		s := &http.Server{
 			Handler: handler,
 		}
@@ -181,7 +470,36 @@ func runHttp(ctx context.Context, log logr.Logger, addr, name string, handler ht
 			}
 		}()
 
-		log.Info(fmt.Sprintf("%s server started", name), "addr", l.Addr().String())
+		log.Info(fmt.Sprintf("%s server started", name), "addr", l.Addr().String(), "tls", tlsConfig != nil)
+		return s.Serve(l)
+	}
+}
+
+func runGrpc(ctx context.Context, log logr.Logger, addr string, s *grpc.Server) func() error {
+
+	return func() error {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("could not listen for grpc requests: %w", err)
+		}
+
+		go func() {
+			<-ctx.Done()
+			log.Info("graceful shutdown of the grpc server")
+			stopped := make(chan struct{})
+			go func() {
+				s.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+			case <-time.After(time.Second):
+				log.Info("grpc server did not shut down gracefully, forcing close")
+				s.Stop()
+			}
+		}()
+
+		log.Info("grpc server started", "addr", l.Addr().String())
 		return s.Serve(l)
 	}
 }
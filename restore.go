@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/draganm/bolted/embedded"
+	"github.com/draganm/event-buffer/server"
+	"github.com/urfave/cli/v2"
+)
+
+var restoreCommand = &cli.Command{
+	Name:      "restore",
+	Usage:     "create a new state file from a backup served by the /backup endpoint",
+	ArgsUsage: "[backup file, - or none for stdin]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "state-file",
+			Value:   "state",
+			EnvVars: []string{"STATE_FILE"},
+		},
+	},
+	Action: func(c *cli.Context) error {
+		stateFile := c.String("state-file")
+
+		_, err := os.Stat(stateFile)
+		if err == nil {
+			return fmt.Errorf("state file %s already exists", stateFile)
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("could not check state file: %w", err)
+		}
+
+		var backup io.Reader = os.Stdin
+		if c.Args().Present() && c.Args().First() != "-" {
+			f, err := os.Open(c.Args().First())
+			if err != nil {
+				return fmt.Errorf("could not open backup: %w", err)
+			}
+			defer f.Close()
+			backup = f
+		}
+
+		db, err := embedded.Open(stateFile, 0700, embedded.Options{})
+		if err != nil {
+			return fmt.Errorf("could not open state: %w", err)
+		}
+
+		stats, err := server.Restore(db, backup)
+		if err != nil {
+			db.Close()
+			// don't leave a partial state file behind
+			os.Remove(stateFile)
+			return fmt.Errorf("could not restore backup: %w", err)
+		}
+
+		err = db.Close()
+		if err != nil {
+			return fmt.Errorf("could not close state: %w", err)
+		}
+
+		fmt.Printf("restored %d entries with %d events into %s\n", stats.Entries, stats.Events, stateFile)
+		return nil
+	},
+}
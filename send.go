@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/draganm/event-buffer/client"
+	"github.com/urfave/cli/v2"
+)
+
+// sendBatchSize is the maximal number of events read from stdin sent in one request.
+const sendBatchSize = 1000
+
+// eventPayload returns the payload as is if it is valid JSON, otherwise as a JSON string.
+func eventPayload(s string) (json.RawMessage, error) {
+	if json.Valid([]byte(s)) {
+		return json.RawMessage(s), nil
+	}
+	return json.Marshal(s)
+}
+
+var sendCommand = &cli.Command{
+	Name:      "send",
+	Usage:     "publish events given as arguments or read from stdin, one per line",
+	ArgsUsage: "[payload...]",
+	Description: "Payloads which are valid JSON are sent as is, other payloads are sent as JSON strings.\n" +
+		"The IDs of the published events are printed one per line.",
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:  "topic",
+			Usage: "topic of the events, the default topic if not set",
+		},
+		&cli.StringFlag{
+			Name:  "key",
+			Usage: "key of the events",
+		},
+		&cli.StringFlag{
+			Name:  "content-type",
+			Usage: "content type of the events",
+		},
+		&cli.StringSliceFlag{
+			Name:  "header",
+			Usage: "header of the events in the form <name>=<value>",
+		},
+	}, clientFlags...),
+	Action: func(c *cli.Context) error {
+		cl, err := topicClient(c)
+		if err != nil {
+			return err
+		}
+
+		headers := map[string]string{}
+		for _, h := range c.StringSlice("header") {
+			name, value, found := strings.Cut(h, "=")
+			if !found {
+				return fmt.Errorf("invalid header %q: must have the form <name>=<value>", h)
+			}
+			headers[name] = value
+		}
+
+		send := func(payloads []string) error {
+			batch := client.Batch{}
+			for _, p := range payloads {
+				payload, err := eventPayload(p)
+				if err != nil {
+					return err
+				}
+				batch.Events = append(batch.Events, client.BatchEvent{
+					Payload:     payload,
+					Headers:     headers,
+					Key:         c.String("key"),
+					ContentType: c.String("content-type"),
+				})
+			}
+
+			published, err := cl.SendBatch(c.Context, batch)
+			if err != nil {
+				return fmt.Errorf("could not send events: %w", err)
+			}
+
+			for _, e := range published {
+				fmt.Println(e.ID)
+			}
+
+			return nil
+		}
+
+		if c.Args().Present() {
+			return send(c.Args().Slice())
+		}
+
+		return sendLines(os.Stdin, send)
+	},
+}
+
+// sendLines calls send with batches of non-empty lines read from r.
+func sendLines(r io.Reader, send func([]string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+
+	batch := []string{}
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		batch = append(batch, line)
+		if len(batch) == sendBatchSize {
+			err := send(batch)
+			if err != nil {
+				return err
+			}
+			batch = []string{}
+		}
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return fmt.Errorf("could not read events: %w", err)
+	}
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	return send(batch)
+}
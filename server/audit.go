@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/draganm/bolted"
+	"github.com/draganm/bolted/dbpath"
+	"github.com/gofrs/uuid"
+)
+
+// auditPath maps topic names to maps of UUIDv6 IDs to audit records of deletions and redactions of the topic's events,
+// in the order of the operations. Records are kept when their topic is deleted.
+var auditPath = dbpath.ToPath("audit")
+
+func topicAuditPath(topic string) dbpath.Path {
+	return auditPath.Append(topic)
+}
+
+type auditRecord struct {
+	ID        string    `json:"id"`
+	Time      time.Time `json:"time"`
+	Operation string    `json:"operation"`
+	Topic     string    `json:"topic"`
+	// Principal is the name of the authenticated principal, empty if authentication is disabled.
+	Principal string   `json:"principal,omitempty"`
+	Reason    string   `json:"reason"`
+	IDs       []string `json:"ids,omitempty"`
+	// Filter has the form <path>:<op>:sha256:<hash of the value>, filter values are not stored
+	// because they may contain the erased data.
+	Filter []string `json:"filter,omitempty"`
+	Fields []string `json:"fields,omitempty"`
+	// Affected are the IDs of the deleted or redacted events.
+	Affected []string `json:"affected"`
+}
+
+// auditFilter replaces the values of the filter expressions with their SHA-256 hashes.
+// The expressions have been validated by parseFilter.
+func auditFilter(expressions []string) []string {
+	hashed := []string{}
+	for _, expr := range expressions {
+		parts := strings.SplitN(expr, ":", 3)
+		sum := sha256.Sum256([]byte(parts[2]))
+		hashed = append(hashed, fmt.Sprintf("%s:%s:sha256:%s", parts[0], parts[1], hex.EncodeToString(sum[:])))
+	}
+	return hashed
+}
+
+// principalName returns the name of the principal of the context.
+func principalName(ctx context.Context) string {
+	p, _ := ctx.Value(principalKey).(Principal)
+	return p.Name
+}
+
+func putAuditRecord(tx bolted.SugaredWriteTx, rec *auditRecord) error {
+	id, err := uuid.NewV6()
+	if err != nil {
+		return fmt.Errorf("could not generate UUID: %w", err)
+	}
+	rec.ID = id.String()
+
+	d, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("could not marshal audit record: %w", err)
+	}
+
+	putAuditRecordData(tx, rec.Topic, rec.ID, d)
+	return nil
+}
+
+func putAuditRecordData(tx bolted.SugaredWriteTx, topic, id string, d []byte) {
+	p := topicAuditPath(topic)
+	if !tx.Exists(p) {
+		tx.CreateMap(p)
+	}
+	tx.Put(p.Append(id), d)
+}
+
+func (s *Server) listAuditRecords(w http.ResponseWriter, r *http.Request) {
+	log := s.log.WithValues("method", r.Method, "path", r.URL.Path)
+
+	topic, err := requestTopic(r)
+	if err != nil {
+		log.Error(err, "invalid topic")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+
+	limit, err := requestLimit(q)
+	if err != nil {
+		log.Error(err, "invalid limit", "limit", q.Get("limit"))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	after := q.Get("after")
+	if after != "" {
+		err = validateEventID(after)
+		if err != nil {
+			log.Error(err, "invalid after")
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	records := []auditRecord{}
+	err = bolted.SugaredRead(s.db, func(tx bolted.SugaredReadTx) error {
+		p := topicAuditPath(topic)
+		if !tx.Exists(p) {
+			return nil
+		}
+		it := tx.Iterator(p)
+		if after != "" {
+			it.Seek(after)
+			if !it.IsDone() && it.GetKey() == after {
+				it.Next()
+			}
+		}
+		for ; !it.IsDone() && len(records) < limit; it.Next() {
+			rec := auditRecord{}
+			err := json.Unmarshal(it.GetValue(), &rec)
+			if err != nil {
+				return fmt.Errorf("could not unmarshal audit record %s: %w", it.GetKey(), err)
+			}
+			records = append(records, rec)
+		}
+		return nil
+	})
+
+	if err != nil {
+		log.Error(err, "could not read audit records")
+		http.Error(w, fmt.Errorf("could not read audit records: %w", err).Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(records)
+}
@@ -0,0 +1,332 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Scope is a kind of access granted to a principal.
+type Scope string
+
+const (
+	ScopePublish Scope = "publish"
+	ScopeRead    Scope = "read"
+	// ScopeAdmin permits managing topics and reading the internal dump,
+	// it implies publish and read.
+	ScopeAdmin Scope = "admin"
+)
+
+// anyTopic grants a scope for all topics.
+const anyTopic = "*"
+
+// Grant permits a scope for a topic, or for all topics if the topic is "*".
+type Grant struct {
+	Scope Scope
+	Topic string
+}
+
+// ParseGrant parses grants of the form <scope>[:<topic>], e.g. "publish:orders" or "read".
+// Grants without a topic apply to all topics.
+func ParseGrant(s string) (Grant, error) {
+	scope, topic, found := strings.Cut(s, ":")
+	if !found {
+		topic = anyTopic
+	}
+
+	g := Grant{Scope: Scope(scope), Topic: topic}
+	switch g.Scope {
+	case ScopePublish, ScopeRead, ScopeAdmin:
+	default:
+		return Grant{}, fmt.Errorf("unknown scope %q", scope)
+	}
+
+	if topic != anyTopic {
+		err := validateTopicName(topic)
+		if err != nil {
+			return Grant{}, err
+		}
+	}
+
+	return g, nil
+}
+
+func (g Grant) String() string {
+	if g.Topic == anyTopic {
+		return string(g.Scope)
+	}
+	return string(g.Scope) + ":" + g.Topic
+}
+
+// Principal is the authenticated caller of the API.
+type Principal struct {
+	Name   string
+	Grants []Grant
+}
+
+func (p Principal) allowed(scope Scope, topic string) bool {
+	for _, g := range p.Grants {
+		if g.Topic != anyTopic && g.Topic != topic {
+			continue
+		}
+		if g.Scope == scope || g.Scope == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator verifies bearer tokens.
+type Authenticator interface {
+	Authenticate(token string) (Principal, error)
+}
+
+var errUnauthenticated = errors.New("invalid credentials")
+
+// APIKeys authenticates static API keys.
+type APIKeys map[string]Principal
+
+type apiKeysFile struct {
+	Keys []struct {
+		Name   string   `json:"name"`
+		Key    string   `json:"key"`
+		Grants []string `json:"grants"`
+	} `json:"keys"`
+}
+
+// LoadAPIKeys reads API keys from a JSON file of the form
+// {"keys": [{"name": "ingest", "key": "...", "grants": ["publish:orders", "read"]}]}.
+func LoadAPIKeys(fileName string) (APIKeys, error) {
+	d, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("could not read API keys: %w", err)
+	}
+
+	f := apiKeysFile{}
+	err = json.Unmarshal(d, &f)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse API keys: %w", err)
+	}
+
+	keys := APIKeys{}
+	for _, k := range f.Keys {
+		if k.Key == "" {
+			return nil, fmt.Errorf("API key %q is empty", k.Name)
+		}
+		p := Principal{Name: k.Name}
+		for _, gs := range k.Grants {
+			g, err := ParseGrant(gs)
+			if err != nil {
+				return nil, fmt.Errorf("API key %q: %w", k.Name, err)
+			}
+			p.Grants = append(p.Grants, g)
+		}
+		keys[hashAPIKey(k.Key)] = p
+	}
+
+	return keys, nil
+}
+
+// hashAPIKey is used to look keys up without comparing secrets in variable time.
+func hashAPIKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
+
+func (k APIKeys) Authenticate(token string) (Principal, error) {
+	p, found := k[hashAPIKey(token)]
+	if !found {
+		return Principal{}, errUnauthenticated
+	}
+	return p, nil
+}
+
+// HMACTokens authenticates tokens signed with a shared secret.
+// Tokens have the form v1.<base64 claims>.<base64 HMAC-SHA256 of the claims>.
+type HMACTokens struct {
+	secret []byte
+}
+
+const hmacTokenPrefix = "v1."
+
+type tokenClaims struct {
+	Subject string   `json:"sub"`
+	Grants  []string `json:"grants"`
+	Expires int64    `json:"exp,omitempty"`
+}
+
+func NewHMACTokens(secret []byte) *HMACTokens {
+	return &HMACTokens{secret: secret}
+}
+
+func (h *HMACTokens) mac(claims string) string {
+	m := hmac.New(sha256.New, h.secret)
+	m.Write([]byte(claims))
+	return base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
+
+// Sign issues a token for the principal, expiring at the given time.
+// A zero expiry issues a token that does not expire.
+func (h *HMACTokens) Sign(p Principal, expires time.Time) (string, error) {
+	c := tokenClaims{Subject: p.Name}
+	for _, g := range p.Grants {
+		c.Grants = append(c.Grants, g.String())
+	}
+	if !expires.IsZero() {
+		c.Expires = expires.Unix()
+	}
+
+	d, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("could not marshal token claims: %w", err)
+	}
+
+	claims := base64.RawURLEncoding.EncodeToString(d)
+	return hmacTokenPrefix + claims + "." + h.mac(claims), nil
+}
+
+func (h *HMACTokens) Authenticate(token string) (Principal, error) {
+	if !strings.HasPrefix(token, hmacTokenPrefix) {
+		return Principal{}, errUnauthenticated
+	}
+
+	claims, mac, found := strings.Cut(strings.TrimPrefix(token, hmacTokenPrefix), ".")
+	if !found || !hmac.Equal([]byte(mac), []byte(h.mac(claims))) {
+		return Principal{}, errUnauthenticated
+	}
+
+	d, err := base64.RawURLEncoding.DecodeString(claims)
+	if err != nil {
+		return Principal{}, errUnauthenticated
+	}
+
+	c := tokenClaims{}
+	err = json.Unmarshal(d, &c)
+	if err != nil {
+		return Principal{}, errUnauthenticated
+	}
+
+	if c.Expires != 0 && time.Now().Unix() >= c.Expires {
+		return Principal{}, fmt.Errorf("token has expired")
+	}
+
+	p := Principal{Name: c.Subject}
+	for _, gs := range c.Grants {
+		g, err := ParseGrant(gs)
+		if err != nil {
+			return Principal{}, fmt.Errorf("invalid token grant: %w", err)
+		}
+		p.Grants = append(p.Grants, g)
+	}
+
+	return p, nil
+}
+
+type principalKeyType struct{}
+
+var principalKey = principalKeyType{}
+
+func withPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey, p)
+}
+
+// authEnabled returns true if requests have to be authenticated.
+func (s *Server) authEnabled() bool {
+	return len(s.authenticators) > 0
+}
+
+// authenticate returns the principal identified by the token.
+func (s *Server) authenticate(token string) (Principal, error) {
+	if token == "" {
+		return Principal{}, errors.New("missing credentials")
+	}
+	var err error
+	for _, a := range s.authenticators {
+		var p Principal
+		p, err = a.Authenticate(token)
+		if err == nil {
+			return p, nil
+		}
+	}
+	return Principal{}, err
+}
+
+// authorized returns true if the principal of the context is permitted the scope on the topic.
+// All requests are authorized when authentication is disabled.
+func (s *Server) authorized(ctx context.Context, scope Scope, topic string) bool {
+	if !s.authEnabled() {
+		return true
+	}
+	p, found := ctx.Value(principalKey).(Principal)
+	return found && p.allowed(scope, topic)
+}
+
+// requestToken returns the bearer token of the request.
+// Browsers can't set headers of WebSocket and EventSource requests,
+// so the token is also accepted in the access_token query parameter.
+func requestToken(r *http.Request) string {
+	auth := r.Header.Get("authorization")
+	if strings.HasPrefix(strings.ToLower(auth), "bearer ") {
+		return strings.TrimSpace(auth[len("bearer "):])
+	}
+	return r.URL.Query().Get("access_token")
+}
+
+// requireScope authenticates the request and checks that the principal is permitted
+// the scope on the requested topic. An empty scope only requires authentication.
+func (s *Server) requireScope(scope Scope, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.authEnabled() {
+			h(w, r)
+			return
+		}
+
+		log := s.log.WithValues("method", r.Method, "path", r.URL.Path)
+
+		p, err := s.authenticate(requestToken(r))
+		if err != nil {
+			log.Info("unauthenticated request", "reason", err.Error())
+			w.Header().Set("www-authenticate", "Bearer")
+			http.Error(w, fmt.Errorf("unauthenticated: %w", err).Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if scope != "" {
+			topic, err := requestTopic(r)
+			if err != nil {
+				log.Error(err, "invalid topic")
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			if !p.allowed(scope, topic) {
+				log.Info("forbidden request", "principal", p.Name, "scope", scope, "topic", topic)
+				http.Error(w, fmt.Sprintf("%s is not permitted to %s topic %s", p.Name, scope, topic), http.StatusForbidden)
+				return
+			}
+		}
+
+		h(w, r.WithContext(withPrincipal(r.Context(), p)))
+	}
+}
+
+// RequireAdmin wraps the handler to permit only requests by principals with the admin scope for all topics.
+// Requests are not checked when authentication is disabled.
+func (s *Server) RequireAdmin(h http.Handler) http.Handler {
+	return s.requireScope("", func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r.Context(), ScopeAdmin, anyTopic) {
+			http.Error(w, "admin scope is required", http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,276 @@
+package server
+
+// Backups are gzip compressed streams of newline delimited JSON objects.
+// The first line is the header:
+//
+//	{"format": "event-buffer-backup", "version": 1, "created": "2023-01-02T15:04:05Z"}
+//
+// followed by one line per map or value of the database, in depth-first order
+// with each map preceding its content. Paths are lists of keys, values are base64 encoded:
+//
+//	{"path": ["events"], "map": true}
+//	{"path": ["events", "1edb3e5e-..."], "value": "AXsicGF5bG9hZCI6..."}
+//
+// The last line is the trailer with the number of entries and the hex encoded SHA-256
+// of all preceding lines, including the header and the newlines:
+//
+//	{"end": true, "entries": 2, "sha256": "9f86d08..."}
+//
+// Backups are taken in a single read transaction, so they are consistent while the server is running.
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+
+	"github.com/draganm/bolted"
+	"github.com/draganm/bolted/dbpath"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	backupFormat  = "event-buffer-backup"
+	backupVersion = 1
+)
+
+type backupHeader struct {
+	Format  string    `json:"format"`
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+}
+
+type backupEntry struct {
+	Path  []string `json:"path,omitempty"`
+	Map   bool     `json:"map,omitempty"`
+	Value []byte   `json:"value,omitempty"`
+
+	End     bool   `json:"end,omitempty"`
+	Entries int64  `json:"entries,omitempty"`
+	SHA256  string `json:"sha256,omitempty"`
+}
+
+// backupWriter writes lines to the compressed stream and hashes them.
+type backupWriter struct {
+	w    *bufio.Writer
+	hash hash.Hash
+}
+
+func (bw *backupWriter) writeLine(v any) error {
+	d, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	d = append(d, '\n')
+	bw.hash.Write(d)
+	_, err = bw.w.Write(d)
+	return err
+}
+
+// Backup writes a consistent backup of the database to w.
+func (s *Server) Backup(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	bw := &backupWriter{w: bufio.NewWriter(gz), hash: sha256.New()}
+
+	err := bolted.SugaredRead(s.db, func(tx bolted.SugaredReadTx) error {
+		err := bw.writeLine(backupHeader{Format: backupFormat, Version: backupVersion, Created: time.Now().UTC()})
+		if err != nil {
+			return err
+		}
+
+		entries := int64(0)
+
+		// walk writes the map and its content, starting at the root of the database which has no entry
+		var walk func(p dbpath.Path) error
+		walk = func(p dbpath.Path) error {
+			if len(p) > 0 {
+				entries++
+				err := bw.writeLine(backupEntry{Path: p, Map: true})
+				if err != nil {
+					return err
+				}
+			}
+			for it := tx.Iterator(p); !it.IsDone(); it.Next() {
+				cp := p.Append(it.GetKey())
+				if tx.IsMap(cp) {
+					err := walk(cp)
+					if err != nil {
+						return err
+					}
+					continue
+				}
+				entries++
+				err := bw.writeLine(backupEntry{Path: cp, Value: it.GetValue()})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		err = walk(dbpath.ToPath())
+		if err != nil {
+			return err
+		}
+
+		// the trailer is not part of the hash
+		return json.NewEncoder(bw.w).Encode(backupEntry{End: true, Entries: entries, SHA256: hex.EncodeToString(bw.hash.Sum(nil))})
+	})
+	if err != nil {
+		return fmt.Errorf("could not write backup: %w", err)
+	}
+
+	err = bw.w.Flush()
+	if err != nil {
+		return fmt.Errorf("could not write backup: %w", err)
+	}
+
+	return gz.Close()
+}
+
+var errInvalidBackup = errors.New("invalid backup")
+
+// RestoreStats summarizes a restored backup.
+type RestoreStats struct {
+	Entries int64
+	Events  int64
+}
+
+// Restore loads the backup into the database, which must not contain any of the restored data.
+// The backup is verified while it is restored: the checksum and number of entries must match the trailer,
+// and events of each topic must have valid IDs in ascending order.
+// Nothing is restored if verification fails.
+func Restore(db bolted.Database, r io.Reader) (RestoreStats, error) {
+	stats := RestoreStats{}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return stats, fmt.Errorf("%w: %s", errInvalidBackup, err.Error())
+	}
+
+	br := bufio.NewReader(gz)
+	h := sha256.New()
+
+	readLine := func() ([]byte, error) {
+		line, err := br.ReadBytes('\n')
+		if err == io.EOF && len(line) > 0 {
+			return nil, fmt.Errorf("%w: truncated line", errInvalidBackup)
+		}
+		return line, err
+	}
+
+	line, err := readLine()
+	if err != nil {
+		return stats, fmt.Errorf("%w: could not read header: %s", errInvalidBackup, err.Error())
+	}
+	h.Write(line)
+
+	header := backupHeader{}
+	err = json.Unmarshal(line, &header)
+	if err != nil || header.Format != backupFormat {
+		return stats, fmt.Errorf("%w: not an event-buffer backup", errInvalidBackup)
+	}
+
+	if header.Version != backupVersion {
+		return stats, fmt.Errorf("%w: unsupported version %d", errInvalidBackup, header.Version)
+	}
+
+	err = bolted.SugaredWrite(db, func(tx bolted.SugaredWriteTx) error {
+		// last event ID per topic, to verify the order of events
+		lastIDs := map[string]string{}
+
+		for {
+			line, err := readLine()
+			if err == io.EOF {
+				return fmt.Errorf("%w: missing trailer", errInvalidBackup)
+			}
+			if err != nil {
+				return fmt.Errorf("could not read backup: %w", err)
+			}
+
+			e := backupEntry{}
+			err = json.Unmarshal(line, &e)
+			if err != nil {
+				return fmt.Errorf("%w: entry %d: %s", errInvalidBackup, stats.Entries+1, err.Error())
+			}
+
+			if e.End {
+				if e.Entries != stats.Entries {
+					return fmt.Errorf("%w: expected %d entries, found %d", errInvalidBackup, e.Entries, stats.Entries)
+				}
+				if e.SHA256 != hex.EncodeToString(h.Sum(nil)) {
+					return fmt.Errorf("%w: checksum mismatch", errInvalidBackup)
+				}
+				return nil
+			}
+
+			h.Write(line)
+			stats.Entries++
+
+			p := dbpath.Path(e.Path)
+			if len(p) == 0 {
+				return fmt.Errorf("%w: entry %d has no path", errInvalidBackup, stats.Entries)
+			}
+
+			if len(p) > 1 && !tx.Exists(p[:len(p)-1]) {
+				return fmt.Errorf("%w: parent of %s is missing", errInvalidBackup, p.String())
+			}
+
+			if tx.Exists(p) {
+				return fmt.Errorf("%s already exists, backups can only be restored into an empty database", p.String())
+			}
+
+			if e.Map {
+				tx.CreateMap(p)
+				continue
+			}
+
+			parent, id := p[:len(p)-1], p[len(p)-1]
+			if isEventsPath(parent) {
+				err = verifyBackupEvent(lastIDs[parent.String()], id, e.Value)
+				if err != nil {
+					return err
+				}
+				lastIDs[parent.String()] = id
+				stats.Events++
+			}
+
+			tx.Put(p, e.Value)
+		}
+	})
+
+	if err != nil {
+		return RestoreStats{}, err
+	}
+
+	return stats, nil
+}
+
+// isEventsPath returns true if the path is the map holding the events of a topic.
+func isEventsPath(p dbpath.Path) bool {
+	return p.Equal(eventsPath) || (len(p) == len(topicsPath)+1 && topicsPath.IsPrefixOf(p))
+}
+
+func verifyBackupEvent(previousID, id string, value []byte) error {
+	u, err := uuid.FromString(id)
+	if err != nil || u.Version() != uuid.V6 {
+		return fmt.Errorf("%w: event ID %s is not a UUIDv6", errInvalidBackup, id)
+	}
+
+	if previousID != "" && id <= previousID {
+		return fmt.Errorf("%w: event %s is not ordered after %s", errInvalidBackup, id, previousID)
+	}
+
+	_, err = decodeEvent(id, value)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errInvalidBackup, err.Error())
+	}
+
+	return nil
+}
@@ -24,16 +24,25 @@ var (
 	bufferSizeCount = prometheus.NewDesc(
 		"event_buffer_size",
 		"Number of events in the buffer.",
-		nil, nil,
+		[]string{"topic"}, nil,
+	)
+	bufferSizeBytes = prometheus.NewDesc(
+		"event_buffer_size_bytes",
+		"Number of bytes occupied by events in the buffer.",
+		[]string{"topic"}, nil,
 	)
 )
 
 func (sc *statsCollector) Collect(ch chan<- prometheus.Metric) {
 
-	var messagesCount float64
+	messageCounts := map[string]float64{}
+	messageBytes := map[string]float64{}
 
 	err := bolted.SugaredRead(sc.db, func(tx bolted.SugaredReadTx) error {
-		messagesCount = float64(tx.Size(eventsPath))
+		for _, topic := range topicNames(tx) {
+			messageCounts[topic] = float64(tx.Size(topicEventsPath(topic)))
+			messageBytes[topic] = float64(topicSize(tx, topic))
+		}
 		return nil
 	})
 
@@ -41,10 +50,22 @@ func (sc *statsCollector) Collect(ch chan<- prometheus.Metric) {
 		sc.log.Error(err, "could not collect metrics")
 	}
 
-	ch <- prometheus.MustNewConstMetric(
-		bufferSizeCount,
-		prometheus.CounterValue,
-		messagesCount,
-	)
+	for topic, count := range messageCounts {
+		ch <- prometheus.MustNewConstMetric(
+			bufferSizeCount,
+			prometheus.CounterValue,
+			count,
+			topic,
+		)
+	}
+
+	for topic, size := range messageBytes {
+		ch <- prometheus.MustNewConstMetric(
+			bufferSizeBytes,
+			prometheus.GaugeValue,
+			size,
+			topic,
+		)
+	}
 
 }
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/draganm/bolted"
+)
+
+// pruneReasonCompacted is used for events deleted because a newer event with the same key exists.
+const pruneReasonCompacted = "compacted"
+
+func (s Server) compactionEnabled(cfg topicConfig) bool {
+	if cfg.Compaction != nil {
+		return *cfg.Compaction
+	}
+	return s.compaction
+}
+
+// isTombstone returns true if the event deletes its key from a compacted topic.
+// Tombstones are events with a key and a null payload.
+func isTombstone(e event) bool {
+	return e.meta.Key != "" && bytes.Equal(bytes.TrimSpace(e.payload), []byte("null"))
+}
+
+// compaction is a plan for compacting a topic, computed from a snapshot of its events.
+// Events published after the snapshot are neither deleted nor retained by the plan.
+// Topics are compacted when the buffer is pruned, not when events are published, as planning reads the whole topic.
+// Compaction doesn't consider the offsets of consumer groups: a group that has not read a
+// superseded event continues with the newer event of the key.
+type compaction struct {
+	// superseded are the IDs of events with a newer event of the same key, oldest first.
+	superseded []string
+	// retained are the IDs of the latest events of keys that were not deleted by a tombstone,
+	// which are kept regardless of their age, but not beyond the maximal number of events or bytes of the topic.
+	// Events without a key and tombstones are subject to all retention limits.
+	retained map[string]bool
+}
+
+// planCompaction reads the events of the topic once to find the superseded and the retained events.
+func planCompaction(tx bolted.SugaredReadTx, topic string) (*compaction, error) {
+	latest := map[string]string{}
+	tombstones := map[string]bool{}
+	superseded := []string{}
+
+	for it := tx.Iterator(topicEventsPath(topic)); !it.IsDone(); it.Next() {
+		e, err := decodeEvent(it.GetKey(), it.GetValue())
+		if err != nil {
+			return nil, err
+		}
+		if e.meta.Key == "" {
+			continue
+		}
+		previous, found := latest[e.meta.Key]
+		if found {
+			superseded = append(superseded, previous)
+		}
+		latest[e.meta.Key] = e.id
+		tombstones[e.meta.Key] = isTombstone(e)
+	}
+
+	sort.Strings(superseded)
+
+	retained := map[string]bool{}
+	for key, id := range latest {
+		if !tombstones[key] {
+			retained[id] = true
+		}
+	}
+
+	return &compaction{superseded: superseded, retained: retained}, nil
+}
+
+// compact deletes up to max superseded events which still exist and returns the number of deleted events.
+func (c *compaction) compact(tx bolted.SugaredWriteTx, topic string, max int) int {
+	topicPath := topicEventsPath(topic)
+	toDelete := []string{}
+	for len(c.superseded) > 0 && len(toDelete) < max {
+		id := c.superseded[0]
+		c.superseded = c.superseded[1:]
+		if tx.Exists(topicPath.Append(id)) {
+			toDelete = append(toDelete, id)
+		}
+	}
+
+	deleteEvents(tx, topic, toDelete)
+
+	return len(toDelete)
+}
+
+// topicCompacted returns true if key-based compaction is enabled for the topic.
+func (s Server) topicCompacted(tx bolted.SugaredReadTx, topic string) (bool, error) {
+	cfg, err := getTopicConfig(tx, topic)
+	if err != nil {
+		return false, err
+	}
+	return s.compactionEnabled(cfg), nil
+}
@@ -0,0 +1,244 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// schemaIncompatibilities returns the reasons why the new version of a schema
+// is not compatible with the previous one in the compatibility mode.
+//
+// The checks are structural: they compare the type, enum, const, required, properties,
+// additionalProperties and items keywords, and the numeric, length and pattern constraints.
+// Other keywords, e.g. combinators and references, are not compared.
+func schemaIncompatibilities(mode string, previous, next json.RawMessage) ([]string, error) {
+	var p, n any
+	err := json.Unmarshal(previous, &p)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal previous schema: %w", err)
+	}
+	err = json.Unmarshal(next, &n)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal new schema: %w", err)
+	}
+
+	problems := []string{}
+	switch mode {
+	case compatibilityBackward:
+		problems = append(problems, readerIncompatibilities(p, n, "")...)
+	case compatibilityForward:
+		problems = append(problems, readerIncompatibilities(n, p, "")...)
+	case compatibilityFull:
+		problems = append(problems, readerIncompatibilities(p, n, "")...)
+		problems = append(problems, readerIncompatibilities(n, p, "")...)
+	}
+
+	return problems, nil
+}
+
+// readerIncompatibilities returns the reasons why values valid against the writer schema
+// may be invalid against the reader schema. The location is the JSON pointer of the schemas.
+func readerIncompatibilities(writer, reader any, location string) []string {
+	w := schemaObject(writer)
+	r := schemaObject(reader)
+
+	if r == nil {
+		// the reader accepts everything, or nothing if it is false
+		if reader == false && writer != false {
+			return []string{fmt.Sprintf("%s: no value is allowed", pointer(location))}
+		}
+		return nil
+	}
+
+	if w == nil {
+		if writer == false {
+			return nil
+		}
+		// the writer accepts everything
+		w = map[string]any{}
+	}
+
+	problems := []string{}
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf("%s: %s", pointer(location), fmt.Sprintf(format, args...)))
+	}
+
+	_, writerTyped := w["type"]
+	_, readerTyped := r["type"]
+	if readerTyped && !writerTyped {
+		add("type restricts values that were allowed")
+	} else {
+		readerTypes := schemaTypes(r)
+		for _, t := range schemaTypes(w) {
+			if !typeAllowed(t, readerTypes) {
+				add("type %s is no longer allowed", t)
+			}
+		}
+	}
+
+	if readerEnum, found := r["enum"].([]any); found {
+		writerEnum, found := w["enum"].([]any)
+		if !found {
+			add("enum restricts values that were allowed")
+		}
+		for _, v := range writerEnum {
+			if !containsValue(readerEnum, v) {
+				add("enum value %v is no longer allowed", v)
+			}
+		}
+	}
+
+	if readerConst, found := r["const"]; found {
+		writerConst, found := w["const"]
+		if !found || !reflect.DeepEqual(writerConst, readerConst) {
+			add("const restricts values that were allowed")
+		}
+	}
+
+	writerRequired := stringSet(w["required"])
+	for _, name := range sortedKeys(stringSet(r["required"])) {
+		if !writerRequired[name] {
+			add("property %s became required", name)
+		}
+	}
+
+	writerProperties, _ := w["properties"].(map[string]any)
+	readerProperties, _ := r["properties"].(map[string]any)
+
+	for _, name := range sortedKeys(writerProperties) {
+		readerProperty, found := readerProperties[name]
+		if !found {
+			readerProperty, found = r["additionalProperties"]
+		}
+		if !found {
+			continue
+		}
+		if readerProperty == false {
+			add("property %s is no longer allowed", name)
+			continue
+		}
+		problems = append(problems, readerIncompatibilities(writerProperties[name], readerProperty, location+"/properties/"+name)...)
+	}
+
+	// properties declared only by the reader were allowed by the writer's additionalProperties
+	for _, name := range sortedKeys(readerProperties) {
+		if _, found := writerProperties[name]; found {
+			continue
+		}
+		writerProperty, found := w["additionalProperties"]
+		if !found {
+			writerProperty = true
+		}
+		problems = append(problems, readerIncompatibilities(writerProperty, readerProperties[name], location+"/properties/"+name)...)
+	}
+
+	if r["additionalProperties"] == false && w["additionalProperties"] != false {
+		add("additional properties are no longer allowed")
+	}
+
+	if readerItems, found := r["items"]; found {
+		writerItems, found := w["items"]
+		if !found {
+			writerItems = true
+		}
+		problems = append(problems, readerIncompatibilities(writerItems, readerItems, location+"/items")...)
+	}
+
+	for _, keyword := range []string{"maximum", "exclusiveMaximum", "maxLength", "maxItems", "maxProperties"} {
+		if readerLimit, found := r[keyword].(float64); found {
+			writerLimit, found := w[keyword].(float64)
+			if !found || writerLimit > readerLimit {
+				add("%s became more restrictive", keyword)
+			}
+		}
+	}
+
+	for _, keyword := range []string{"minimum", "exclusiveMinimum", "minLength", "minItems", "minProperties"} {
+		if readerLimit, found := r[keyword].(float64); found {
+			writerLimit, found := w[keyword].(float64)
+			if !found || writerLimit < readerLimit {
+				add("%s became more restrictive", keyword)
+			}
+		}
+	}
+
+	if readerPattern, found := r["pattern"]; found && w["pattern"] != readerPattern {
+		add("pattern changed")
+	}
+
+	return problems
+}
+
+// schemaObject returns the schema as a map, or nil for the boolean schemas true and false.
+func schemaObject(schema any) map[string]any {
+	m, _ := schema.(map[string]any)
+	return m
+}
+
+var allSchemaTypes = []string{"array", "boolean", "integer", "null", "number", "object", "string"}
+
+// schemaTypes returns the types allowed by the type keyword of the schema, all types if it is not set.
+func schemaTypes(schema map[string]any) []string {
+	switch t := schema["type"].(type) {
+	case string:
+		return []string{t}
+	case []any:
+		types := []string{}
+		for _, v := range t {
+			s, ok := v.(string)
+			if ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	default:
+		return allSchemaTypes
+	}
+}
+
+func typeAllowed(t string, allowed []string) bool {
+	for _, a := range allowed {
+		// integers are numbers
+		if a == t || (t == "integer" && a == "number") {
+			return true
+		}
+	}
+	return false
+}
+
+func containsValue(values []any, v any) bool {
+	for _, e := range values {
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func stringSet(v any) map[string]bool {
+	set := map[string]bool{}
+	values, _ := v.([]any)
+	for _, e := range values {
+		s, ok := e.(string)
+		if ok {
+			set[s] = true
+		}
+	}
+	return set
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// pointer returns the JSON pointer, using # for the root.
+func pointer(location string) string {
+	return "#" + location
+}
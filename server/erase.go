@@ -0,0 +1,306 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/draganm/bolted"
+)
+
+const (
+	eraseOpDelete = "delete"
+	eraseOpRedact = "redact"
+)
+
+// defaultRedactionReplacement replaces redacted fields unless the request sets another replacement.
+var defaultRedactionReplacement = json.RawMessage(`"[redacted]"`)
+
+// eraseRequest selects events of a topic for deletion or redaction.
+// Events are selected by their IDs, by a filter on their payloads (see parseFilter) or both.
+type eraseRequest struct {
+	IDs    []string `json:"ids,omitempty"`
+	Filter []string `json:"filter,omitempty"`
+	// Fields are the paths of the payload fields replaced when redacting, in the syntax of filter paths.
+	Fields []string `json:"fields,omitempty"`
+	// Replacement is the JSON value replacing redacted fields.
+	Replacement json.RawMessage `json:"replacement,omitempty"`
+	// Reason is recorded in the audit record.
+	Reason string `json:"reason"`
+}
+
+func (req eraseRequest) validate(op string) error {
+	if len(req.IDs) == 0 && len(req.Filter) == 0 {
+		return errors.New("ids or filter are required")
+	}
+	if len(req.IDs) > maxLookupIDs {
+		return fmt.Errorf("more than %d ids", maxLookupIDs)
+	}
+	for _, id := range req.IDs {
+		err := validateEventID(id)
+		if err != nil {
+			return err
+		}
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		return errors.New("reason is required")
+	}
+	if op == eraseOpRedact {
+		if len(req.Fields) == 0 {
+			return errors.New("fields are required")
+		}
+		for _, f := range req.Fields {
+			if len(fieldPath(f)) == 0 {
+				return fmt.Errorf("invalid field %q", f)
+			}
+		}
+		if len(req.Replacement) > 0 && !json.Valid(req.Replacement) {
+			return errors.New("replacement is not valid JSON")
+		}
+	}
+	return nil
+}
+
+// fieldPath splits the path of a payload field, see parseFilter.
+func fieldPath(field string) []string {
+	path := strings.TrimPrefix(strings.TrimPrefix(field, "$"), ".")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, ".")
+}
+
+// selectEvents returns the events of the topic selected by the IDs and the filter.
+func selectEvents(tx bolted.SugaredReadTx, topic string, ids []string, filter eventFilter) ([]event, error) {
+	topicPath := topicEventsPath(topic)
+	if !tx.Exists(topicPath) {
+		return nil, errTopicNotFound
+	}
+
+	selected := []event{}
+
+	if len(ids) > 0 {
+		seen := map[string]bool{}
+		for _, id := range ids {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			e, err := getEvent(tx, topic, id)
+			if errors.Is(err, errEventNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if filter.matches(e) {
+				selected = append(selected, e)
+			}
+		}
+		return selected, nil
+	}
+
+	for it := tx.Iterator(topicPath); !it.IsDone(); it.Next() {
+		e, err := decodeEvent(it.GetKey(), it.GetValue())
+		if err != nil {
+			return nil, err
+		}
+		if filter.matches(e) {
+			selected = append(selected, e)
+		}
+	}
+
+	return selected, nil
+}
+
+// redactPayload replaces the fields of the JSON payload which exist with the replacement.
+// Object keys of the rewritten payload are sorted.
+func redactPayload(payload json.RawMessage, fields [][]string, replacement any) (json.RawMessage, bool, error) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	// keep numbers as they are
+	dec.UseNumber()
+
+	var v any
+	err := dec.Decode(&v)
+	if err != nil {
+		// payloads which are not JSON have no fields
+		return payload, false, nil
+	}
+
+	redacted := false
+	for _, path := range fields {
+		if replacePath(v, path, replacement) {
+			redacted = true
+		}
+	}
+
+	if !redacted {
+		return payload, false, nil
+	}
+
+	d, err := json.Marshal(v)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not marshal redacted payload: %w", err)
+	}
+
+	return d, true, nil
+}
+
+// replacePath replaces the value at the path, returning false if there is no such value.
+func replacePath(v any, path []string, replacement any) bool {
+	parent, found := lookupPath(v, path[:len(path)-1])
+	if !found {
+		return false
+	}
+
+	key := path[len(path)-1]
+	switch c := parent.(type) {
+	case map[string]any:
+		if _, found := c[key]; !found {
+			return false
+		}
+		c[key] = replacement
+		return true
+	case []any:
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(c) {
+			return false
+		}
+		c[i] = replacement
+		return true
+	}
+
+	return false
+}
+
+// eraseEvents deletes or redacts the selected events of the topic and stores the audit record.
+// Followers replicate deletions and redactions from the audit log, see Server.Follow.
+func (s *Server) eraseEvents(topic, op string, req eraseRequest, rec *auditRecord) error {
+	filter, err := parseFilter(req.Filter)
+	if err != nil {
+		return err
+	}
+
+	fields := [][]string{}
+	for _, f := range req.Fields {
+		fields = append(fields, fieldPath(f))
+	}
+
+	replacement := req.Replacement
+	if len(replacement) == 0 {
+		replacement = defaultRedactionReplacement
+	}
+
+	return bolted.SugaredWrite(s.db, func(tx bolted.SugaredWriteTx) error {
+		selected, err := selectEvents(tx, topic, req.IDs, filter)
+		if err != nil {
+			return err
+		}
+
+		affected := []string{}
+
+		switch op {
+		case eraseOpDelete:
+			for _, e := range selected {
+				affected = append(affected, e.id)
+			}
+			deleteEvents(tx, topic, affected)
+
+		case eraseOpRedact:
+			ids := []string{}
+			values := [][]byte{}
+			for _, e := range selected {
+				payload, redacted, err := redactPayload(e.payload, fields, replacement)
+				if err != nil {
+					return err
+				}
+				if !redacted {
+					continue
+				}
+				value, err := encodeEvent(payload, e.meta)
+				if err != nil {
+					return err
+				}
+				ids = append(ids, e.id)
+				values = append(values, value)
+			}
+			putEvents(tx, topic, ids, values)
+			affected = ids
+		}
+
+		rec.Time = time.Now().UTC()
+		rec.Operation = op
+		rec.Topic = topic
+		rec.Reason = req.Reason
+		rec.IDs = req.IDs
+		rec.Filter = auditFilter(req.Filter)
+		rec.Fields = req.Fields
+		rec.Affected = affected
+
+		return putAuditRecord(tx, rec)
+	})
+}
+
+func (s *Server) deleteEvents(w http.ResponseWriter, r *http.Request) {
+	s.handleErase(w, r, eraseOpDelete)
+}
+
+func (s *Server) redactEvents(w http.ResponseWriter, r *http.Request) {
+	s.handleErase(w, r, eraseOpRedact)
+}
+
+func (s *Server) handleErase(w http.ResponseWriter, r *http.Request, op string) {
+	log := s.log.WithValues("method", r.Method, "path", r.URL.Path)
+
+	topic, err := requestTopic(r)
+	if err != nil {
+		log.Error(err, "invalid topic")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req := eraseRequest{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Error(err, "could not decode request")
+		http.Error(w, fmt.Errorf("could not decode request: %w", err).Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = req.validate(op)
+	if err != nil {
+		log.Error(err, "invalid request")
+		http.Error(w, fmt.Errorf("invalid request: %w", err).Error(), http.StatusBadRequest)
+		return
+	}
+
+	rec := &auditRecord{Principal: principalName(r.Context())}
+	err = s.eraseEvents(topic, op, req, rec)
+
+	if errors.Is(err, errInvalidRequest) {
+		log.Error(err, "invalid filter")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if errors.Is(err, errTopicNotFound) {
+		http.Error(w, fmt.Errorf("topic %s: %w", topic, err).Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		log.Error(err, "could not erase events", "operation", op, "topic", topic)
+		http.Error(w, fmt.Errorf("could not %s events: %w", op, err).Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Info("events erased", "operation", op, "topic", topic, "principal", rec.Principal, "reason", rec.Reason, "events", len(rec.Affected), "audit", rec.ID)
+
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(rec)
+}
@@ -1,12 +1,99 @@
 package server
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// envelopeMediaType is accepted by clients reading events with their metadata.
+// Other clients get events as [id, payload] tuples.
+const envelopeMediaType = "application/vnd.event-buffer.envelope+json"
+
+func acceptsEnvelopes(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("accept"), envelopeMediaType)
+}
 
 type event struct {
 	id      string
 	payload json.RawMessage
+	meta    eventMeta
+}
+
+// eventMeta is the metadata stored alongside the event payload.
+type eventMeta struct {
+	Headers map[string]string `json:"headers,omitempty"`
+	// Key is the optional partition/entity key of the event.
+	Key         string `json:"key,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	// ReceivedAt is the time the server received the event.
+	// It is not set for events stored before metadata was introduced.
+	ReceivedAt *time.Time `json:"receivedAt,omitempty"`
 }
 
 func (e event) MarshalJSON() ([]byte, error) {
 	return json.Marshal([]any{e.id, e.payload})
 }
+
+// eventEnvelope marshals the event with its metadata as a JSON object.
+type eventEnvelope event
+
+func (e eventEnvelope) MarshalJSON() ([]byte, error) {
+	t, err := eventTime(e.id)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		ID      string          `json:"id"`
+		Time    time.Time       `json:"time"`
+		Payload json.RawMessage `json:"payload"`
+		eventMeta
+	}{
+		ID:        e.id,
+		Time:      t.UTC(),
+		Payload:   e.payload,
+		eventMeta: e.meta,
+	})
+}
+
+func toEnvelopes(events []event) []eventEnvelope {
+	envelopes := make([]eventEnvelope, len(events))
+	for i, e := range events {
+		envelopes[i] = eventEnvelope(e)
+	}
+	return envelopes
+}
+
+// envelopeFormatV1 prefixes stored values containing an envelope.
+// Values stored before the envelope was introduced are bare JSON payloads,
+// which can never start with this byte.
+const envelopeFormatV1 byte = 1
+
+type storedEnvelope struct {
+	Payload json.RawMessage `json:"payload"`
+	eventMeta
+}
+
+func encodeEvent(payload json.RawMessage, meta eventMeta) ([]byte, error) {
+	d, err := json.Marshal(storedEnvelope{Payload: payload, eventMeta: meta})
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal event envelope: %w", err)
+	}
+	return append([]byte{envelopeFormatV1}, d...), nil
+}
+
+func decodeEvent(id string, value []byte) (event, error) {
+	if len(value) == 0 || value[0] != envelopeFormatV1 {
+		return event{id: id, payload: value}, nil
+	}
+
+	se := storedEnvelope{}
+	err := json.Unmarshal(value[1:], &se)
+	if err != nil {
+		return event{}, fmt.Errorf("could not unmarshal envelope of event %s: %w", id, err)
+	}
+
+	return event{id: id, payload: se.Payload, meta: se.eventMeta}, nil
+}
@@ -0,0 +1,204 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/draganm/bolted"
+	"github.com/gofrs/uuid"
+)
+
+// ExportedEvent is an event in the newline delimited JSON format of the export and import commands.
+type ExportedEvent struct {
+	Topic string `json:"topic"`
+	ID    string `json:"id,omitempty"`
+	// Time is derived from the UUIDv6 ID.
+	Time        time.Time         `json:"time"`
+	Payload     json.RawMessage   `json:"payload"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Key         string            `json:"key,omitempty"`
+	ContentType string            `json:"contentType,omitempty"`
+	ReceivedAt  *time.Time        `json:"receivedAt,omitempty"`
+}
+
+// EventRange selects events by their IDs and times. Zero values don't bound the range.
+type EventRange struct {
+	// AfterID excludes the event with the ID and all events before it.
+	AfterID string
+	// UntilID excludes all events after the event with the ID.
+	UntilID string
+	// Since excludes events created before the time.
+	Since time.Time
+	// Until excludes events created at or after the time.
+	Until time.Time
+}
+
+// Contains returns true if the event created at t with the ID is in the range.
+func (r EventRange) Contains(id string, t time.Time) bool {
+	if r.AfterID != "" && id <= r.AfterID {
+		return false
+	}
+	if !r.Since.IsZero() && t.Before(r.Since) {
+		return false
+	}
+	return !r.Past(id, t)
+}
+
+// Past returns true if the event created at t with the ID and all events after it are after the range.
+func (r EventRange) Past(id string, t time.Time) bool {
+	if r.UntilID != "" && id > r.UntilID {
+		return true
+	}
+	return !r.Until.IsZero() && !t.Before(r.Until)
+}
+
+func exportedEvent(topic string, e event) (ExportedEvent, error) {
+	t, err := eventTime(e.id)
+	if err != nil {
+		return ExportedEvent{}, err
+	}
+	return ExportedEvent{
+		Topic:       topic,
+		ID:          e.id,
+		Time:        t.UTC(),
+		Payload:     e.payload,
+		Headers:     e.meta.Headers,
+		Key:         e.meta.Key,
+		ContentType: e.meta.ContentType,
+		ReceivedAt:  e.meta.ReceivedAt,
+	}, nil
+}
+
+// ExportEvents calls fn with the events of the topics in the range, in the order they were stored.
+// All topics are exported if no topics are given.
+// It only reads the database, which can be opened read-only without starting a server.
+// The events are read in a single transaction, fn should not block for long.
+func ExportEvents(db bolted.Database, topics []string, r EventRange, fn func(ExportedEvent) error) error {
+	return bolted.SugaredRead(db, func(tx bolted.SugaredReadTx) error {
+		if len(topics) == 0 {
+			topics = topicNames(tx)
+		}
+
+		for _, topic := range topics {
+			topicPath := topicEventsPath(topic)
+			if !tx.Exists(topicPath) {
+				return fmt.Errorf("topic %s: %w", topic, errTopicNotFound)
+			}
+
+			start := r.AfterID
+			if !r.Since.IsZero() && timeID(r.Since) > start {
+				start = timeID(r.Since)
+			}
+
+			it := tx.Iterator(topicPath)
+			if start != "" {
+				it.Seek(start)
+			}
+
+			for ; !it.IsDone(); it.Next() {
+				t, err := eventTime(it.GetKey())
+				if err != nil {
+					return err
+				}
+
+				if r.Past(it.GetKey(), t) {
+					break
+				}
+
+				if !r.Contains(it.GetKey(), t) {
+					continue
+				}
+
+				e, err := decodeEvent(it.GetKey(), it.GetValue())
+				if err != nil {
+					return err
+				}
+
+				ee, err := exportedEvent(topic, e)
+				if err != nil {
+					return err
+				}
+
+				err = fn(ee)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// ImportEvents stores the events with their original IDs, creating missing topics.
+// Events without an ID are assigned a new one. The IDs must be ascending
+// and newer than the last event of the topic, so that consumers don't miss imported events.
+// next returns false when there are no more events. All events are imported in a single transaction.
+// Imported events are not validated against the schemas of their topics.
+func (s *Server) ImportEvents(next func() (ExportedEvent, bool, error)) (int, error) {
+	imported := 0
+
+	err := bolted.SugaredWrite(s.db, func(tx bolted.SugaredWriteTx) error {
+		for {
+			ee, more, err := next()
+			if err != nil {
+				return err
+			}
+
+			if !more {
+				return nil
+			}
+
+			err = validateTopicName(ee.Topic)
+			if err != nil {
+				return fmt.Errorf("event %d: %w", imported+1, err)
+			}
+
+			createTopic(tx, ee.Topic)
+
+			if len(ee.Payload) == 0 {
+				return fmt.Errorf("event %d has no payload", imported+1)
+			}
+
+			id := ee.ID
+			if id == "" {
+				u, err := uuid.NewV6()
+				if err != nil {
+					return fmt.Errorf("could not generate UUID: %w", err)
+				}
+				id = u.String()
+			}
+
+			u, err := uuid.FromString(id)
+			if err != nil || u.Version() != uuid.V6 {
+				return fmt.Errorf("event %d: ID %s is not a UUIDv6", imported+1, id)
+			}
+
+			it := tx.Iterator(topicEventsPath(ee.Topic))
+			it.Last()
+			if !it.IsDone() && it.GetKey() >= id {
+				return fmt.Errorf("event %d: ID %s is not newer than the last event %s of the topic %s", imported+1, id, it.GetKey(), ee.Topic)
+			}
+
+			value, err := encodeEvent(ee.Payload, eventMeta{
+				Headers:     ee.Headers,
+				Key:         ee.Key,
+				ContentType: ee.ContentType,
+				ReceivedAt:  ee.ReceivedAt,
+			})
+			if err != nil {
+				return err
+			}
+
+			putEvents(tx, ee.Topic, []string{id}, [][]byte{value})
+			imported++
+		}
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return imported, nil
+}
@@ -0,0 +1,72 @@
+Feature: authentication
+
+    Background:
+        Given a server with the API keys
+            """
+            {
+                "keys": [
+                    {"name": "admin", "key": "admin-key", "grants": ["admin"]},
+                    {"name": "orders-writer", "key": "writer-key", "grants": ["publish:orders"]},
+                    {"name": "reader", "key": "reader-key", "grants": ["read"]}
+                ]
+            }
+            """
+
+    Scenario: rejecting requests without credentials
+        When I try to send the events "evt1"
+        Then the request should have been rejected with status 401
+
+    Scenario: rejecting requests with unknown credentials
+        Given I use the token "unknown-key"
+        When I try to send the events "evt1"
+        Then the request should have been rejected with status 401
+
+    Scenario: publishing to a permitted topic
+        Given I use the token "admin-key"
+        And a topic named "orders"
+        And I use the token "writer-key"
+        When I send an event "order1" to the topic "orders"
+        And I use the token "reader-key"
+        Then polling the topic "orders" should return "order1"
+
+    Scenario: publishing to a topic that is not permitted
+        Given I use the token "writer-key"
+        When I try to send the events "evt1"
+        Then the request should have been rejected with status 403
+
+    Scenario: managing topics requires the admin scope
+        Given I use the token "reader-key"
+        When I try to create the topic "orders"
+        Then the request should have been rejected with status 403
+
+    Scenario: authenticating with a signed token
+        Given a server verifying signed tokens
+        And I use a signed token granting "publish,read"
+        When I send the events "evt1"
+        Then polling the default topic should return "evt1"
+
+    Scenario: rejecting gRPC requests without credentials
+        Given I have a gRPC connection
+        When I try to publish "evt1" over gRPC
+        Then the gRPC request should have been rejected as unauthenticated
+
+    Scenario: deleting events requires the admin scope
+        Given I use the token "admin-key"
+        And I send the events "evt1"
+        And I use the token "reader-key"
+        When I try to delete the first event because "erasure request"
+        Then the request should have been rejected with status 403
+
+    Scenario: audit records name the principal
+        Given I use the token "admin-key"
+        And I send the events "evt1,evt2"
+        When I delete the second event because "erasure request"
+        Then the audit log should name the principal "admin"
+
+    Scenario: registering schemas requires the admin scope
+        Given I use the token "reader-key"
+        When I try to register the schema of the topic "default" with "none" compatibility
+            """
+            {"type": "object"}
+            """
+        Then the request should have been rejected with status 403
@@ -0,0 +1,40 @@
+Feature: backup and restore
+
+    Scenario: restoring a backup
+        Given a topic named "orders"
+        And I send an event "order1" to the topic "orders"
+        And I send the events "evt1,evt2"
+        And the consumer group "billing" polls for one event and commits it
+        When I restore a backup of the server
+        Then polling the default topic should return "evt1,evt2"
+        And polling the topic "orders" should return "order1"
+        And the committed offset of "billing" should be the ID of the first event
+        And the consumer group "billing" polls for events
+        And the consumer group should receive "evt2"
+
+    Scenario: restoring every map of the database
+        Given the schema of the topic "default" is
+            """
+            {"type": "string"}
+            """
+        And I send the events "evt1,evt2"
+        And I send the batch "evt3" with the idempotency key "batch-1"
+        And the consumer group "billing" polls for one event and commits it
+        And I delete the second event because "erasure request 1"
+        When I restore a backup of the server
+        Then the restored database should have the same content as the backed up database
+
+    Scenario: rejecting a modified backup
+        Given I send the events "evt1,evt2"
+        When I try to restore a backup of the server with a modified event
+        Then restoring should have failed with "checksum mismatch"
+
+    Scenario: rejecting a backup with events out of order
+        Given I send the events "evt1,evt2"
+        When I try to restore a backup of the server with swapped events
+        Then restoring should have failed with "is not ordered after"
+
+    Scenario: rejecting a truncated backup
+        Given I send the events "evt1,evt2"
+        When I try to restore a truncated backup of the server
+        Then restoring should have failed with "missing trailer"
@@ -0,0 +1,57 @@
+Feature: client retries
+
+    Scenario: retrying failed requests
+        Given a client retrying publishes through a faulty proxy with at most 3 attempts
+        And the proxy fails the next 2 requests
+        When I send the events "evt1"
+        Then polling the default topic should return "evt1"
+
+    Scenario: retrying a publish whose response was lost
+        Given a client retrying publishes through a faulty proxy with at most 3 attempts
+        And the proxy loses the response of the next request
+        When I send the events "evt1,evt2"
+        Then polling the default topic should return "evt1,evt2"
+
+    Scenario: not retrying publishes by default
+        Given a client connected through a faulty proxy with at most 3 attempts
+        And the proxy fails the next 1 requests
+        When I try to send the events "evt1"
+        Then the request should have been rejected with status 503
+
+    Scenario: retrying a batch with an idempotency key by default
+        Given a client connected through a faulty proxy with at most 3 attempts
+        And the proxy fails the next 1 requests
+        When I send the batch "evt1" with the idempotency key "batch-1"
+        Then polling the default topic should return "evt1"
+
+    Scenario: giving up after the maximal number of attempts
+        Given a client retrying publishes through a faulty proxy with at most 2 attempts
+        And the proxy fails the next 2 requests
+        When I try to send the events "evt1"
+        Then sending should have failed
+
+    Scenario: opening the circuit after repeated failures
+        Given a client connected through a faulty proxy with a circuit breaker opening after 2 failures
+        And the proxy fails the next 2 requests
+        When I try to send the events "evt1"
+        And I try to send the events "evt1"
+        And I try to send the events "evt1"
+        Then sending should have failed because the circuit is open
+
+    Scenario: not retrying deletions
+        Given a client connected through a faulty proxy with at most 3 attempts
+        And I send the events "evt1"
+        And the proxy fails the next 1 requests
+        When I try to delete the first event because "erasure request 1"
+        Then the request should have been rejected with status 503
+
+    Scenario: probing the circuit again after a cancelled probe
+        Given a client connected through a faulty proxy with a circuit breaker opening after 2 failures for 50 milliseconds
+        And the proxy fails the next 2 requests
+        And I try to send the events "evt1"
+        And I try to send the events "evt1"
+        And the circuit breaker has been open for 100 milliseconds
+        And the proxy stalls the next request
+        When I try to send the events "evt1" and cancel after 50 milliseconds
+        And I send the events "evt2"
+        Then polling the default topic should return "evt2"
@@ -0,0 +1,50 @@
+Feature: key-based compaction
+
+    Scenario: keeping the latest event of each key
+        Given a compacted topic named "users"
+        When I send the keyed events "alice=v1,bob=v1,alice=v2,v3" to the topic "users"
+        And the buffer is pruned
+        Then the topic "users" should contain "bob=v1,alice=v2,v3"
+
+    Scenario: deleting a key with a tombstone
+        Given a compacted topic named "users"
+        When I send the keyed events "alice=v1,bob=v1" to the topic "users"
+        And I send a tombstone for the key "alice" to the topic "users"
+        And the buffer is pruned
+        Then the topic "users" should contain "bob=v1,alice=null"
+        When the buffer is pruned of all events older than now
+        Then the topic "users" should contain "bob=v1"
+
+    Scenario: compacting topics by default
+        Given a server compacting topics by default
+        And a topic named "history"
+        When I disable compaction of the topic "history"
+        And I send the keyed events "alice=v1,alice=v2" to the topic "default"
+        And I send the keyed events "alice=v1,alice=v2" to the topic "history"
+        And the buffer is pruned
+        Then the topic "default" should be compacted
+        And the topic "history" should not be compacted
+        And the topic "default" should contain "alice=v2"
+        And the topic "history" should contain "alice=v1,alice=v2"
+
+    Scenario: topics are not compacted unless configured
+        Given a topic named "history"
+        When I send the keyed events "alice=v1,alice=v2" to the topic "history"
+        And the buffer is pruned
+        Then the topic "history" should not be compacted
+        And the topic "history" should contain "alice=v1,alice=v2"
+
+    Scenario: compacting topics when the buffer is pruned
+        Given a server retaining at most 10 events per topic on write
+        And a compacted topic named "users"
+        When I send the keyed events "alice=v1,alice=v2" to the topic "users"
+        Then the topic "users" should contain "alice=v1,alice=v2"
+        When the buffer is pruned
+        Then the topic "users" should contain "alice=v2"
+
+    Scenario: limiting the number of events of a compacted topic
+        Given a server retaining at most 2 events per topic
+        And a compacted topic named "users"
+        When I send the keyed events "alice=v1,bob=v1,carol=v1" to the topic "users"
+        And the buffer is pruned
+        Then the topic "users" should contain "bob=v1,carol=v1"
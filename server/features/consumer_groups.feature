@@ -0,0 +1,46 @@
+Feature: consumer groups
+
+    Scenario: consumer group resumes after the committed event
+        Given two events in the buffer
+        When the consumer group "billing" polls for one event and commits it
+        And the consumer group "billing" polls for events
+        Then the consumer group should receive "evt2"
+
+    Scenario: consumer group without commits starts at the beginning
+        Given two events in the buffer
+        When the consumer group "billing" polls for events
+        Then the consumer group should receive "evt1,evt2"
+
+    Scenario: consumer groups are independent
+        Given two events in the buffer
+        When the consumer group "billing" polls for one event and commits it
+        And the consumer group "shipping" polls for events
+        Then the consumer group should receive "evt1,evt2"
+
+    Scenario: reading the committed offset
+        Given two events in the buffer
+        When the consumer group "billing" polls for one event and commits it
+        Then the committed offset of "billing" should be the ID of the first event
+
+    Scenario Outline: rejecting offsets which are not events of the topic
+        Given I send the events "evt1,evt2"
+        When the consumer group "billing" tries to commit <offset>
+        Then the request should have been rejected with status 400
+
+        Examples:
+            | offset                       |
+            | a random UUIDv4              |
+            | an ID after the newest event |
+
+    Scenario: rejecting commits moving the offset backwards
+        Given I send the events "evt1,evt2"
+        And the consumer group "billing" commits the second event
+        When the consumer group "billing" tries to commit the first event
+        Then the request should have been rejected with status 409
+
+    Scenario: resetting the offset of a consumer group
+        Given I send the events "evt1,evt2"
+        And the consumer group "billing" commits the second event
+        When the consumer group "billing" is reset to the first event
+        And the consumer group "billing" polls for events
+        Then the consumer group should receive "evt2"
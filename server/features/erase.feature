@@ -0,0 +1,50 @@
+Feature: deleting and redacting events
+
+    Scenario: deleting events by ID
+        Given I send the events "evt1,evt2,evt3"
+        When I delete the second event because "erasure request 1"
+        Then polling the default topic should return "evt1,evt3"
+        And the audit log should have a "delete" record with the reason "erasure request 1" affecting 1 event
+
+    Scenario: reading the audit log in pages
+        Given I send the events "evt1,evt2,evt3"
+        When I try to delete the first event because "erasure request 1"
+        And I delete the second event because "erasure request 2"
+        Then reading the audit log in pages of 1 record should return the reasons "erasure request 1,erasure request 2"
+
+    Scenario: deleting events matching a filter
+        Given the events
+            """
+            [
+                {"user": "alice", "id": "a1"},
+                {"user": "bob", "id": "b1"},
+                {"user": "alice", "id": "a2"}
+            ]
+            """
+        When I delete the events matching "user:eq:alice" because "erasure request 2"
+        And I poll for the events matching "id:prefix:"
+        Then I should receive the events with the IDs "b1"
+        And the audit log should have a "delete" record with the reason "erasure request 2" affecting 2 events
+        And the audit log should not contain "alice"
+
+    Scenario: redacting fields of events
+        Given the events
+            """
+            [
+                {"user": {"name": "alice", "email": "alice@example.com"}, "id": "a1"},
+                {"user": {"name": "bob"}, "id": "b1"}
+            ]
+            """
+        When I redact the field "user.email" of the events matching "user.name:eq:alice" because "erasure request 3"
+        And I get the first event
+        Then the event should have the JSON payload
+            """
+            {"id": "a1", "user": {"email": "[redacted]", "name": "alice"}}
+            """
+        And the audit log should have a "redact" record with the reason "erasure request 3" affecting 1 event
+        And the audit log should not contain "alice"
+
+    Scenario: rejecting deletions without a reason
+        Given I send the events "evt1"
+        When I try to delete the first event without a reason
+        Then the request should have been rejected with status 400
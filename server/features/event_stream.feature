@@ -0,0 +1,13 @@
+Feature: streaming events
+
+    Scenario: streaming buffered and new events
+        Given one event in the buffer
+        When I open an event stream
+        And I send an event "evt2" to the topic "default"
+        Then the event stream should deliver "evt1,evt2"
+
+    Scenario: resuming a stream with the last event ID
+        Given two events in the buffer
+        When I poll for one event
+        And I open an event stream with the polled event as last event ID
+        Then the event stream should deliver "evt2"
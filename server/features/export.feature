@@ -0,0 +1,46 @@
+Feature: export and import
+
+    Scenario: reading events without waiting
+        Given I send the events "evt1,evt2"
+        Then reading the default topic without waiting should return "evt1,evt2"
+
+    Scenario: reading an empty topic without waiting
+        Given a topic named "orders"
+        Then reading the topic "orders" without waiting should return no events
+
+    Scenario: exporting a range of events
+        Given I send the events "evt1,evt2,evt3"
+        When I export the events after the first one up to the second one
+        Then the exported events should be "evt2"
+
+    Scenario: exporting all topics
+        Given a topic named "orders"
+        And I send an event "order1" to the topic "orders"
+        And I send the events "evt1"
+        When I export the events
+        Then the exported events should be "evt1,order1"
+
+    Scenario: importing exported events keeps their IDs
+        Given a topic named "orders"
+        And I send an event "order1" to the topic "orders"
+        And I send the events "evt1,evt2"
+        And I export the events
+        When I import the exported events into a new server
+        Then polling the default topic should return "evt1,evt2"
+        And polling the topic "orders" should return "order1"
+        And the events of the new server should have the exported IDs
+
+    Scenario: importing events without IDs
+        When I import the events
+            """
+            {"topic": "orders", "payload": "order1"}
+            {"payload": "evt1", "key": "k1"}
+            """
+        Then polling the topic "orders" should return "order1"
+        And polling the default topic should return "evt1"
+
+    Scenario: rejecting events older than the topic
+        Given I send the events "evt1,evt2"
+        And I export the events
+        When I import the exported events
+        Then importing should have failed with "is not newer than the last event"
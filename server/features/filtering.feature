@@ -0,0 +1,45 @@
+Feature: filtering events
+
+    Background:
+        Given the events
+            """
+            [
+                {"type": "order", "id": "o1", "amount": 50},
+                {"type": "payment", "id": "p1", "amount": 50},
+                {"type": "order", "id": "o2", "amount": 150},
+                {"type": "refund", "id": "r1", "amount": 10, "tags": ["late"]}
+            ]
+            """
+
+    Scenario: filtering by equality
+        When I poll for the events matching "type:eq:order"
+        Then I should receive the events with the IDs "o1,o2"
+
+    Scenario: filtering by a set of values
+        When I poll for the events matching "type:in:payment,refund"
+        Then I should receive the events with the IDs "p1,r1"
+
+    Scenario: filtering by a prefix
+        When I poll for the events matching "$.id:prefix:o"
+        Then I should receive the events with the IDs "o1,o2"
+
+    Scenario: filtering by a numeric comparison
+        When I poll for the events matching "amount:gte:50;type:eq:order"
+        Then I should receive the events with the IDs "o1,o2"
+
+    Scenario: filtering by an array element
+        When I poll for the events matching "tags.0:eq:late"
+        Then I should receive the events with the IDs "r1"
+
+    Scenario: continuing from the cursor
+        When I poll for one event matching "amount:eq:50"
+        And I poll for the events matching "amount:lt:100" after the cursor
+        Then I should receive the events with the IDs "p1,r1"
+
+    Scenario: waiting for matching events
+        When I start polling for the events matching "type:eq:shipment"
+        And I send the events
+            """
+            [{"type": "order", "id": "o3"}, {"type": "shipment", "id": "s1"}]
+            """
+        Then the poll should return the events with the IDs "s1"
@@ -0,0 +1,14 @@
+Feature: gRPC API
+
+    Scenario: publishing and polling over gRPC
+        Given I have a gRPC connection
+        When I publish "evt1" over gRPC
+        Then polling over gRPC should return "evt1"
+        And polling the default topic should return "evt1"
+
+    Scenario: subscribing over gRPC
+        Given one event in the buffer
+        And I have a gRPC connection
+        When I subscribe over gRPC
+        And I send an event "evt2" to the topic "default"
+        Then the gRPC subscription should deliver "evt1,evt2"
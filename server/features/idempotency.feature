@@ -0,0 +1,22 @@
+Feature: idempotent publishing
+
+    Scenario: retrying a batch with the same idempotency key
+        When I send the batch "evt1" with the idempotency key "batch-1"
+        And I send the batch "evt1" with the idempotency key "batch-1"
+        Then both sends should have returned the same IDs
+        And polling the default topic should return "evt1"
+
+    Scenario: retrying events with idempotency keys
+        When I send the event "evt1" with the idempotency key "event-1" together with "evt2"
+        And I send the event "evt1" with the idempotency key "event-1" together with "evt3"
+        Then polling the default topic should return "evt1,evt2,evt3"
+
+    Scenario: reusing a batch key as an event key
+        When I send the batch "evt1" with the idempotency key "key-1"
+        And I send the event "evt2" with the idempotency key "key-1" together with "evt3"
+        Then polling the default topic should return "evt1,evt2,evt3"
+
+    Scenario: reusing an event key as a batch key
+        When I send the event "evt1" with the idempotency key "key-1" together with "evt2"
+        And I send the batch "evt3" with the idempotency key "key-1"
+        Then polling the default topic should return "evt1,evt2,evt3"
@@ -0,0 +1,23 @@
+Feature: looking up events by ID
+
+    Scenario: getting an event
+        Given I send the events "evt1,evt2"
+        When I get the second event
+        Then the event should have the payload "evt2"
+
+    Scenario: getting an event of a topic
+        Given a topic named "orders"
+        And I send an event "order1" to the topic "orders"
+        When I get the event sent to the topic "orders"
+        Then the event should have the payload "order1"
+
+    Scenario: getting a missing event
+        Given I send the events "evt1"
+        When I try to get an event that doesn't exist
+        Then the event should not have been found
+
+    Scenario: getting multiple events
+        Given I send the events "evt1,evt2,evt3"
+        When I get the third and the first event and an event that doesn't exist
+        Then the events should be "evt3,evt1"
+        And one event should be missing
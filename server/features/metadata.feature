@@ -0,0 +1,13 @@
+Feature: event metadata
+
+    Scenario: reading events with metadata
+        When I send the event "evt1" with the key "user-1", the content type "application/vnd.user+json" and the header "source" set to "test"
+        And I poll for the events with metadata
+        Then the polled event should have the key "user-1"
+        And the polled event should have the content type "application/vnd.user+json"
+        And the polled event should have the header "source" set to "test"
+        And the polled event should have a receive time
+
+    Scenario: reading events without metadata as tuples
+        When I send the event "evt1" with the key "user-1", the content type "application/vnd.user+json" and the header "source" set to "test"
+        Then polling the default topic should return "evt1"
@@ -0,0 +1,25 @@
+Feature: producing events asynchronously
+
+    Scenario: publishing full batches
+        Given a producer with a batch size of 2 and a linger time of 1h
+        When I produce the events "evt1,evt2,evt3,evt4"
+        Then all deliveries should succeed
+        And polling the default topic should return "evt1,evt2,evt3,evt4"
+
+    Scenario: publishing after the linger time
+        Given a producer with a batch size of 100 and a linger time of 10ms
+        When I produce the events "evt1,evt2"
+        Then all deliveries should succeed
+        And polling the default topic should return "evt1,evt2"
+
+    Scenario: publishing pending events on close
+        Given a producer with a batch size of 100 and a linger time of 1h
+        When I produce the events "evt1"
+        And I close the producer
+        Then all deliveries should succeed
+        And polling the default topic should return "evt1"
+
+    Scenario: failing deliveries
+        Given a producer for the topic "missing"
+        When I produce the events "evt1"
+        Then all deliveries should fail
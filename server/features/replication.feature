@@ -0,0 +1,93 @@
+Feature: replication
+
+    Scenario: replicating events of all topics
+        Given a topic named "orders"
+        And I send an event "order1" to the topic "orders"
+        And I send the events "evt1,evt2"
+        When a follower replicates the server
+        Then the follower should eventually have the same events as the server in the topic "default"
+        And the follower should eventually have the same events as the server in the topic "orders"
+
+    Scenario: replicating new events
+        Given a follower replicates the server
+        When I send the events "evt1,evt2"
+        Then the follower should eventually have the same events as the server in the topic "default"
+
+    Scenario: replicating consumer group offsets
+        Given I send the events "evt1,evt2"
+        And the consumer group "g1" polls for one event and commits it
+        When a follower replicates the server
+        Then the follower should eventually have the committed offset of "g1"
+
+    Scenario: rejecting writes on a follower
+        Given a follower replicates the server
+        When I try to send the events "evt1" to the follower
+        Then the request should have been rejected with status 421
+
+    Scenario: forwarding writes to the leader
+        Given a follower replicates the server and forwards writes
+        When I send the events "evt1" to the follower
+        Then polling the default topic should return "evt1"
+
+    Scenario: replicating deletions
+        Given I send the events "evt1,evt2,evt3"
+        And a follower replicates the server
+        And the follower should eventually have the same events as the server in the topic "default"
+        When I delete the second event because "erasure request 1"
+        Then the follower should eventually have the same events as the server in the topic "default"
+        And the follower should eventually have the same audit records as the server
+
+    Scenario: replicating redactions
+        Given the events
+            """
+            [
+                {"user": {"name": "alice", "email": "alice@example.com"}, "id": "a1"},
+                {"user": {"name": "bob"}, "id": "b1"}
+            ]
+            """
+        And a follower replicates the server
+        And the follower should eventually have the same events as the server in the topic "default"
+        When I redact the field "user.email" of the events matching "user.name:eq:alice" because "erasure request 2"
+        Then the follower should eventually have the same events as the server in the topic "default"
+        And the follower should eventually have the same audit records as the server
+
+    Scenario: replicating events deleted before they were replicated
+        Given I send the events "evt1,evt2,evt3"
+        And I delete the second event because "erasure request 3"
+        When a follower replicates the server
+        Then the follower should eventually have the same events as the server in the topic "default"
+        And the follower should eventually have the same audit records as the server
+
+    Scenario: rejecting gRPC publishes on a follower forwarding writes
+        Given a follower replicates the server and forwards writes
+        And I have a gRPC connection to the follower
+        When I try to publish "evt1" over gRPC
+        Then the gRPC request should have been rejected as a failed precondition
+        And the default topic should be empty
+
+    Scenario: deleting topics deleted on the leader
+        Given a topic named "orders"
+        And I send an event "order1" to the topic "orders"
+        And a follower replicates the server
+        And the follower should eventually have the same events as the server in the topic "orders"
+        When I delete the topic "orders"
+        Then the follower should eventually not have the topic "orders"
+
+    Scenario: keeping topics the follower is not permitted to read
+        Given a server with the API keys
+            """
+            {
+                "keys": [
+                    {"name": "admin", "key": "admin-key", "grants": ["admin"]},
+                    {"name": "orders-reader", "key": "orders-reader-key", "grants": ["read:orders"]}
+                ]
+            }
+            """
+        And I use the token "admin-key"
+        And a topic named "orders"
+        And a topic named "payments"
+        And I send an event "payment1" to the topic "payments"
+        And a follower replicates the server
+        And the follower should eventually have the same events as the server in the topic "payments"
+        When the follower continues replicating with the token "orders-reader-key"
+        Then the follower should still have the topic "payments" after 500 milliseconds
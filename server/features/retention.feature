@@ -0,0 +1,37 @@
+Feature: retention
+
+    Scenario: pruning events exceeding the count limit
+        Given a server retaining at most 2 events per topic
+        When I send the events "evt1,evt2,evt3"
+        And the buffer is pruned
+        Then polling the default topic should return "evt2,evt3"
+
+    Scenario: enforcing the count limit on write
+        Given a server retaining at most 2 events per topic on write
+        When I send the events "evt1,evt2,evt3"
+        Then polling the default topic should return "evt2,evt3"
+
+    Scenario: pruning events exceeding the size limit
+        Given a server retaining at most 250 bytes per topic
+        When I send the events "evt1,evt2,evt3"
+        And the buffer is pruned
+        Then polling the default topic should return "evt2,evt3"
+
+    Scenario: pruning events by age
+        When I send the events "evt1,evt2"
+        And the buffer is pruned of all events older than now
+        Then the default topic should be empty
+
+    Scenario: keeping events not committed by all consumer groups
+        Given a server keeping uncommitted events for at most 1h
+        When I send the events "evt1,evt2,evt3"
+        And the consumer group "g1" polls for one event and commits it
+        And the buffer is pruned of all events older than now
+        Then polling the default topic should return "evt2,evt3"
+
+    Scenario: pruning uncommitted events exceeding the maximal age
+        Given a server keeping uncommitted events for at most 1ns
+        When I send the events "evt1,evt2,evt3"
+        And the consumer group "g1" polls for one event and commits it
+        And the buffer is pruned of all events older than now
+        Then the default topic should be empty
@@ -0,0 +1,135 @@
+Feature: JSON schema validation
+
+    Background:
+        Given the schema of the topic "default" is
+            """
+            {
+                "type": "object",
+                "properties": {"name": {"type": "string"}},
+                "required": ["name"]
+            }
+            """
+
+    Scenario: accepting events matching the schema
+        When I try to send the batch
+            """
+            [{"payload": {"name": "alice"}}, {"payload": {"name": "bob"}}]
+            """
+        Then the events should have been accepted
+
+    Scenario: rejecting batches with events not matching the schema
+        When I try to send the batch
+            """
+            [{"payload": {"name": "alice"}}, {"payload": {"name": 42}}, {"payload": {}}]
+            """
+        Then the request should have been rejected with status 400
+        And the event 1 should have been rejected at "/name" with "expected string, but got number"
+        And the event 2 should have been rejected at "" with "missing properties: 'name'"
+        And the default topic should be empty
+
+    Scenario: validating events against the schema of their type
+        Given the schema of the event type "deleted" of the topic "default" is
+            """
+            {"type": "object", "properties": {"id": {"type": "integer"}}, "required": ["id"]}
+            """
+        When I try to send the batch
+            """
+            [
+                {"payload": {"id": 1}, "headers": {"type": "deleted"}},
+                {"payload": {"name": "alice"}, "headers": {"type": "created"}},
+                {"payload": {"name": "bob"}}
+            ]
+            """
+        Then the events should have been accepted
+        When I try to send the batch
+            """
+            [{"payload": {"name": "alice"}, "headers": {"type": "deleted"}}]
+            """
+        Then the event 0 should have been rejected at "" with "missing properties: 'id'"
+
+    Scenario: registering a compatible version
+        When I try to register the schema of the topic "default" with "backward" compatibility
+            """
+            {
+                "type": "object",
+                "properties": {"name": {"type": "string"}}
+            }
+            """
+        Then the topic "default" should have the schema versions "1,2"
+
+    Scenario: rejecting an incompatible version
+        Given the schema of the topic "default" is registered with "backward" compatibility
+            """
+            {"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"]}
+            """
+        When I try to register the schema of the topic "default" with "backward" compatibility
+            """
+            {
+                "type": "object",
+                "properties": {"name": {"type": "string"}, "email": {"type": "string"}},
+                "required": ["name", "email"]
+            }
+            """
+        Then the request should have been rejected with status 409
+        And the topic "default" should have the schema versions "1,2"
+
+    Scenario: deleting a schema
+        When I delete the schema of the topic "default"
+        And I try to send the batch
+            """
+            [{"payload": "anything"}]
+            """
+        Then the events should have been accepted
+
+    Scenario: validating against a schema replacing a deleted one
+        Given I try to send the batch
+            """
+            [{"payload": {"name": "alice"}}]
+            """
+        And the events should have been accepted
+        When I delete the schema of the topic "default"
+        And the schema of the topic "default" is
+            """
+            {"type": "object", "properties": {"id": {"type": "integer"}}, "required": ["id"]}
+            """
+        And I try to send the batch
+            """
+            [{"payload": {"name": "alice"}}]
+            """
+        Then the event 0 should have been rejected at "" with "missing properties: 'id'"
+
+    Scenario: rejecting a new property restricting values that were allowed
+        Given the schema of the topic "default" is registered with "backward" compatibility
+            """
+            {"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"]}
+            """
+        When I try to register the schema of the topic "default" with "full" compatibility
+            """
+            {
+                "type": "object",
+                "properties": {"name": {"type": "string"}, "amount": {"type": "integer"}},
+                "required": ["name"]
+            }
+            """
+        Then the request should have been rejected with status 409
+        And the topic "default" should have the schema versions "1,2"
+
+    Scenario: adding a property to a schema without additional properties
+        Given the schema of the topic "default" is registered with "none" compatibility
+            """
+            {
+                "type": "object",
+                "properties": {"name": {"type": "string"}},
+                "required": ["name"],
+                "additionalProperties": false
+            }
+            """
+        When I try to register the schema of the topic "default" with "backward" compatibility
+            """
+            {
+                "type": "object",
+                "properties": {"name": {"type": "string"}, "amount": {"type": "integer"}},
+                "required": ["name"]
+            }
+            """
+        Then the topic "default" should have the schema versions "1,2,3"
@@ -0,0 +1,43 @@
+Feature: seeking by time
+
+    Scenario: polling events since a time
+        Given I send the events "evt1"
+        And I remember the current time
+        And I send the events "evt2,evt3"
+        When I poll the events since the remembered time
+        Then the polled events should be "evt2,evt3"
+
+    Scenario: polling events until a time
+        Given I send the events "evt1,evt2"
+        And I remember the current time
+        And I send the events "evt3"
+        When I poll the events until the remembered time
+        Then the polled events should be "evt1,evt2"
+
+    Scenario: polling past the end of a time range
+        Given I remember the current time
+        And I send the events "evt1"
+        When I poll the events until the remembered time
+        Then the polled events should be ""
+
+    Scenario: replaying events of a time range
+        Given I send the events "evt1"
+        And I remember the current time
+        And I send the events "evt2,evt3"
+        And I remember the current time as the end
+        And I send the events "evt4"
+        When I replay the events between the remembered times
+        Then the replayed events should be "evt2,evt3"
+
+    Scenario Outline: rejecting since together with after or group
+        Given I send the events "evt1,evt2"
+        And the consumer group "g1" polls for one event and commits it
+        When I try to poll the events since now with "<parameters>"
+        Then the request should have been rejected with status 400
+
+        Examples:
+            | parameters           |
+            | after=first          |
+            | group=g1             |
+            | group=g2             |
+            | after=first&group=g1 |
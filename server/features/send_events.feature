@@ -3,3 +3,8 @@ Feature: send events
     Scenario: send a single event
         When I send a single event
         Then I should get a confirmation
+
+
+    Scenario: legacy clients get an empty response
+        When I send a single event without accepting a response body
+        Then the response body should be empty
@@ -0,0 +1,15 @@
+Feature: stats
+
+    Scenario: stats of topics
+        Given a topic named "orders"
+        And I send the events "evt1,evt2,evt3"
+        When I get the stats
+        Then the stats of the topic "default" should show 3 events
+        And the stats of the topic "orders" should show 0 events
+        And the newest event of the topic "default" should be the last sent event
+
+    Scenario: lag of consumer groups
+        Given I send the events "evt1,evt2,evt3"
+        And the consumer group "billing" polls for one event and commits it
+        When I get the stats
+        Then the lag of the consumer group "billing" of the topic "default" should be 2
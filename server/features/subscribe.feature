@@ -0,0 +1,31 @@
+Feature: subscribing with the client
+
+    Background:
+        Given the events
+            """
+            [{"type": "order", "id": "o1"}, {"type": "payment", "id": "p1"}]
+            """
+
+    Scenario: receiving stored and new events
+        When I subscribe to the events
+        And I send the events
+            """
+            [{"type": "order", "id": "o2"}]
+            """
+        Then the subscription should deliver the events with the IDs "o1,p1,o2"
+
+    Scenario: subscribing after an event
+        When I subscribe to the events after the first sent event
+        Then the subscription should deliver the events with the IDs "p1"
+
+    Scenario: subscribing with a filter
+        When I subscribe to the events matching "type:eq:order"
+        And I send the events
+            """
+            [{"type": "payment", "id": "p2"}, {"type": "order", "id": "o2"}]
+            """
+        Then the subscription should deliver the events with the IDs "o1,o2"
+
+    Scenario: subscribing to a missing topic
+        When I subscribe to the events of the topic "missing"
+        Then the subscription should fail
@@ -0,0 +1,39 @@
+Feature: TLS
+
+    Scenario: serving the API over TLS
+        Given a server with TLS
+        And a client trusting the test CA
+        When I send the events "evt1"
+        Then polling the default topic should return "evt1"
+
+    Scenario: requiring client certificates
+        Given a server with mutual TLS
+        And a client trusting the test CA
+        When I try to send the events "evt1"
+        Then sending should have failed
+
+    Scenario: authenticating with a client certificate
+        Given a server with mutual TLS
+        And a client with a client certificate
+        When I send the events "evt1"
+        Then polling the default topic should return "evt1"
+
+    Scenario: reloading the server certificate
+        Given a server with TLS
+        When the server certificate is replaced
+        Then the server should present the new certificate
+
+    Scenario: forwarding writes to a leader requiring client certificates
+        Given a server with mutual TLS
+        And a client with a client certificate
+        And a follower forwarding writes with a client certificate
+        When I send the events "evt1" to the follower
+        Then polling the default topic should return "evt1"
+
+    Scenario: serving gRPC over mutual TLS
+        Given a server with mutual TLS
+        And a client with a client certificate
+        And I have a gRPC connection with the client certificate
+        When I publish "evt1" over gRPC
+        Then polling over gRPC should return "evt1"
+        And the server should negotiate HTTP/2 with the client certificate
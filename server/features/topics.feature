@@ -0,0 +1,23 @@
+Feature: topics
+
+    Scenario: events are isolated per topic
+        Given a topic named "orders"
+        When I send an event "order1" to the topic "orders"
+        And I send a single event
+        Then polling the topic "orders" should return "order1"
+        And polling the default topic should return "evt1"
+
+    Scenario: listing topics
+        Given a topic named "orders"
+        When I list the topics
+        Then the topics should be "default,orders"
+
+    Scenario: deleting a topic
+        Given a topic named "orders"
+        When I delete the topic "orders"
+        And I list the topics
+        Then the topics should be "default"
+
+    Scenario: sending events to a missing topic
+        When I send an event "order1" to the topic "missing"
+        Then sending should have failed
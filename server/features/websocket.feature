@@ -0,0 +1,14 @@
+Feature: websocket
+
+    Scenario: publishing over a websocket
+        Given I have a websocket connection
+        When I publish "evt1" over the websocket
+        Then the websocket should confirm the published event
+        And polling the default topic should return "evt1"
+
+    Scenario: subscribing over a websocket
+        Given one event in the buffer
+        And I have a websocket connection
+        When I subscribe over the websocket
+        And I send an event "evt2" to the topic "default"
+        Then the websocket should deliver "evt1,evt2"
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// eventFilter selects events whose JSON payload matches all predicates.
+// A nil filter matches all events.
+type eventFilter []predicate
+
+// predicate compares the value at a path of the event payload with an operand.
+type predicate struct {
+	path    []string
+	op      string
+	operand any
+}
+
+const (
+	filterOpEq     = "eq"
+	filterOpIn     = "in"
+	filterOpPrefix = "prefix"
+	filterOpGt     = "gt"
+	filterOpGte    = "gte"
+	filterOpLt     = "lt"
+	filterOpLte    = "lte"
+)
+
+const maxFilterPredicates = 20
+
+// parseFilter parses filter expressions of the form <path>:<op>:<value>.
+// The path is a dot separated list of object keys and array indexes, optionally prefixed with "$.".
+// Values are JSON. Values which are not valid JSON are taken as strings.
+// The value of the in operator is a JSON array or a comma separated list.
+func parseFilter(expressions []string) (eventFilter, error) {
+	if len(expressions) == 0 {
+		return nil, nil
+	}
+
+	if len(expressions) > maxFilterPredicates {
+		return nil, fmt.Errorf("%w: more than %d filters", errInvalidRequest, maxFilterPredicates)
+	}
+
+	f := eventFilter{}
+	for _, expr := range expressions {
+		p, err := parsePredicate(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: filter %q: %s", errInvalidRequest, expr, err.Error())
+		}
+		f = append(f, p)
+	}
+
+	return f, nil
+}
+
+func parsePredicate(expr string) (predicate, error) {
+	parts := strings.SplitN(expr, ":", 3)
+	if len(parts) != 3 {
+		return predicate{}, fmt.Errorf("must have the form <path>:<op>:<value>")
+	}
+
+	path := strings.TrimPrefix(strings.TrimPrefix(parts[0], "$"), ".")
+	if path == "" {
+		return predicate{}, fmt.Errorf("path is empty")
+	}
+
+	p := predicate{path: strings.Split(path, "."), op: parts[1]}
+	value := parts[2]
+
+	switch p.op {
+	case filterOpEq:
+		p.operand = parseOperand(value)
+	case filterOpIn:
+		values := []any{}
+		err := json.Unmarshal([]byte(value), &values)
+		if err != nil {
+			values = nil
+			for _, v := range strings.Split(value, ",") {
+				values = append(values, parseOperand(v))
+			}
+		}
+		p.operand = values
+	case filterOpPrefix:
+		p.operand = value
+	case filterOpGt, filterOpGte, filterOpLt, filterOpLte:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return predicate{}, fmt.Errorf("value of %s must be a number", p.op)
+		}
+		p.operand = n
+	default:
+		return predicate{}, fmt.Errorf("unknown operator %q", p.op)
+	}
+
+	return p, nil
+}
+
+func parseOperand(value string) any {
+	var v any
+	err := json.Unmarshal([]byte(value), &v)
+	if err != nil {
+		return value
+	}
+	return v
+}
+
+// matches returns true if the event payload matches all predicates of the filter.
+// Payloads which can't be parsed don't match non-empty filters.
+func (f eventFilter) matches(e event) bool {
+	if len(f) == 0 {
+		return true
+	}
+
+	var payload any
+	err := json.Unmarshal(e.payload, &payload)
+	if err != nil {
+		return false
+	}
+
+	for _, p := range f {
+		v, found := lookupPath(payload, p.path)
+		if !found || !p.matches(v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p predicate) matches(v any) bool {
+	switch p.op {
+	case filterOpEq:
+		return reflect.DeepEqual(v, p.operand)
+	case filterOpIn:
+		for _, o := range p.operand.([]any) {
+			if reflect.DeepEqual(v, o) {
+				return true
+			}
+		}
+		return false
+	case filterOpPrefix:
+		s, isString := v.(string)
+		return isString && strings.HasPrefix(s, p.operand.(string))
+	}
+
+	n, isNumber := v.(float64)
+	if !isNumber {
+		return false
+	}
+
+	operand := p.operand.(float64)
+	switch p.op {
+	case filterOpGt:
+		return n > operand
+	case filterOpGte:
+		return n >= operand
+	case filterOpLt:
+		return n < operand
+	case filterOpLte:
+		return n <= operand
+	}
+
+	return false
+}
+
+func lookupPath(v any, path []string) (any, bool) {
+	for _, key := range path {
+		switch c := v.(type) {
+		case map[string]any:
+			var found bool
+			v, found = c[key]
+			if !found {
+				return nil, false
+			}
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, false
+			}
+			v = c[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
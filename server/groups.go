@@ -0,0 +1,238 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/draganm/bolted"
+	"github.com/draganm/bolted/dbpath"
+	"github.com/gofrs/uuid"
+	"github.com/gorilla/mux"
+)
+
+// consumerGroupsPath holds one map per topic, mapping
+// consumer group names to the ID of the last committed event.
+var consumerGroupsPath = dbpath.ToPath("consumer-groups")
+
+var groupNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,200}$`)
+
+var errGroupNotFound = errors.New("consumer group not found")
+
+var errOffsetBehind = errors.New("offset is before the committed offset")
+
+func validateGroupName(name string) error {
+	if !groupNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid consumer group name %q: must match %s", name, groupNameRegexp.String())
+	}
+	return nil
+}
+
+func topicGroupsPath(topic string) dbpath.Path {
+	return consumerGroupsPath.Append(topic)
+}
+
+// committedOffset returns the last event ID committed by the group,
+// or an empty string if the group has not committed yet.
+func committedOffset(tx bolted.SugaredReadTx, topic, group string) string {
+	p := topicGroupsPath(topic).Append(group)
+	if !tx.Exists(p) {
+		return ""
+	}
+	return string(tx.Get(p))
+}
+
+func groupOffsets(tx bolted.SugaredReadTx, topic string) []groupOffset {
+	offsets := []groupOffset{}
+	p := topicGroupsPath(topic)
+	if !tx.Exists(p) {
+		return offsets
+	}
+	for it := tx.Iterator(p); !it.IsDone(); it.Next() {
+		offsets = append(offsets, groupOffset{Group: it.GetKey(), ID: string(it.GetValue())})
+	}
+	return offsets
+}
+
+type commitRequest struct {
+	ID string `json:"id"`
+	// Reset allows moving the offset before the committed offset, so the group reads events again.
+	Reset bool `json:"reset,omitempty"`
+}
+
+// validateOffset checks that the ID of a committed event is a UUIDv6 which is not after the newest event of the topic,
+// or after the current time if the topic is empty.
+func validateOffset(tx bolted.SugaredReadTx, topic, id string) error {
+	u, err := uuid.FromString(id)
+	if err != nil || u.Version() != uuid.V6 {
+		return fmt.Errorf("%w: invalid event id %q", errInvalidRequest, id)
+	}
+
+	newest := timeID(time.Now())
+	it := tx.Iterator(topicEventsPath(topic))
+	it.Last()
+	if !it.IsDone() {
+		newest = it.GetKey()
+	}
+
+	if id > newest {
+		return fmt.Errorf("%w: event id %q is after the newest event", errInvalidRequest, id)
+	}
+
+	return nil
+}
+
+type groupOffset struct {
+	Group string `json:"group"`
+	ID    string `json:"id"`
+}
+
+func (s *Server) commitGroupOffset(w http.ResponseWriter, r *http.Request) {
+	log := s.log.WithValues("method", r.Method, "path", r.URL.Path)
+
+	topic, err := requestTopic(r)
+	if err != nil {
+		log.Error(err, "invalid topic")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	group := mux.Vars(r)["group"]
+	err = validateGroupName(group)
+	if err != nil {
+		log.Error(err, "invalid consumer group")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cr := commitRequest{}
+	err = json.NewDecoder(r.Body).Decode(&cr)
+	if err != nil {
+		log.Error(err, "could not decode request")
+		http.Error(w, fmt.Errorf("could not decode request: %w", err).Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = bolted.SugaredWrite(s.db, func(tx bolted.SugaredWriteTx) error {
+		if !tx.Exists(topicEventsPath(topic)) {
+			return errTopicNotFound
+		}
+		err := validateOffset(tx, topic, cr.ID)
+		if err != nil {
+			return err
+		}
+		committed := committedOffset(tx, topic, group)
+		if !cr.Reset && cr.ID < committed {
+			return fmt.Errorf("%w %s, reset the group to read events again", errOffsetBehind, committed)
+		}
+		p := topicGroupsPath(topic)
+		if !tx.Exists(p) {
+			tx.CreateMap(p)
+		}
+		tx.Put(p.Append(group), []byte(cr.ID))
+		return nil
+	})
+
+	if errors.Is(err, errTopicNotFound) {
+		http.Error(w, fmt.Errorf("topic %s: %w", topic, err).Error(), http.StatusNotFound)
+		return
+	}
+
+	if errors.Is(err, errInvalidRequest) {
+		log.Error(err, "invalid offset", "id", cr.ID)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if errors.Is(err, errOffsetBehind) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	if err != nil {
+		log.Error(err, "could not commit offset", "topic", topic, "group", group)
+		http.Error(w, fmt.Errorf("could not commit offset: %w", err).Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) getGroupOffset(w http.ResponseWriter, r *http.Request) {
+	log := s.log.WithValues("method", r.Method, "path", r.URL.Path)
+
+	topic, err := requestTopic(r)
+	if err != nil {
+		log.Error(err, "invalid topic")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	group := mux.Vars(r)["group"]
+	err = validateGroupName(group)
+	if err != nil {
+		log.Error(err, "invalid consumer group")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var id string
+	err = bolted.SugaredRead(s.db, func(tx bolted.SugaredReadTx) error {
+		id = committedOffset(tx, topic, group)
+		if id == "" {
+			return errGroupNotFound
+		}
+		return nil
+	})
+
+	if errors.Is(err, errGroupNotFound) {
+		http.Error(w, fmt.Errorf("group %s: %w", group, err).Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		log.Error(err, "could not read offset", "topic", topic, "group", group)
+		http.Error(w, fmt.Errorf("could not read offset: %w", err).Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(groupOffset{Group: group, ID: id})
+}
+
+func (s *Server) listGroupOffsets(w http.ResponseWriter, r *http.Request) {
+	log := s.log.WithValues("method", r.Method, "path", r.URL.Path)
+
+	topic, err := requestTopic(r)
+	if err != nil {
+		log.Error(err, "invalid topic")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var offsets []groupOffset
+	err = bolted.SugaredRead(s.db, func(tx bolted.SugaredReadTx) error {
+		if !tx.Exists(topicEventsPath(topic)) {
+			return errTopicNotFound
+		}
+		offsets = groupOffsets(tx, topic)
+		return nil
+	})
+
+	if errors.Is(err, errTopicNotFound) {
+		http.Error(w, fmt.Errorf("topic %s: %w", topic, err).Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		log.Error(err, "could not read offsets", "topic", topic)
+		http.Error(w, fmt.Errorf("could not read offsets: %w", err).Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(offsets)
+}
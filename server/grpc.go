@@ -0,0 +1,228 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/draganm/event-buffer/eventbufferpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// RegisterGRPC registers the gRPC API backed by the server's store.
+func (s *Server) RegisterGRPC(gs *grpc.Server) {
+	eventbufferpb.RegisterEventBufferServer(gs, &grpcServer{s: s})
+}
+
+type grpcServer struct {
+	eventbufferpb.UnimplementedEventBufferServer
+	s *Server
+}
+
+func grpcTopic(topic string) (string, error) {
+	if topic == "" {
+		return DefaultTopic, nil
+	}
+	err := validateTopicName(topic)
+	if err != nil {
+		return "", status.Error(codes.InvalidArgument, err.Error())
+	}
+	return topic, nil
+}
+
+// grpcError maps store errors to gRPC status errors.
+func grpcError(err error) error {
+	switch {
+	case errors.Is(err, errTopicNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, errInvalidRequest):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// authorize checks that the bearer token in the authorization metadata permits the scope on the topic.
+func (g *grpcServer) authorize(ctx context.Context, scope Scope, topic string) error {
+	if !g.s.authEnabled() {
+		return nil
+	}
+
+	token := ""
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, auth := range md.Get("authorization") {
+		if strings.HasPrefix(strings.ToLower(auth), "bearer ") {
+			token = strings.TrimSpace(auth[len("bearer "):])
+		}
+	}
+
+	p, err := g.s.authenticate(token)
+	if err != nil {
+		return status.Errorf(codes.Unauthenticated, "unauthenticated: %s", err.Error())
+	}
+
+	if !p.allowed(scope, topic) {
+		return status.Errorf(codes.PermissionDenied, "%s is not permitted to %s topic %s", p.Name, scope, topic)
+	}
+
+	return nil
+}
+
+func toProtoEvents(events []event) []*eventbufferpb.Event {
+	pe := make([]*eventbufferpb.Event, len(events))
+	for i, e := range events {
+		pe[i] = &eventbufferpb.Event{
+			Id:          e.id,
+			Payload:     e.payload,
+			Headers:     e.meta.Headers,
+			Key:         e.meta.Key,
+			ContentType: e.meta.ContentType,
+		}
+		if e.meta.ReceivedAt != nil {
+			pe[i].ReceivedAt = timestamppb.New(*e.meta.ReceivedAt)
+		}
+	}
+	return pe
+}
+
+func (g *grpcServer) Publish(ctx context.Context, req *eventbufferpb.PublishRequest) (*eventbufferpb.PublishResponse, error) {
+	topic, err := grpcTopic(req.Topic)
+	if err != nil {
+		return nil, err
+	}
+
+	err = g.authorize(ctx, ScopePublish, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	if g.s.isFollower() {
+		// only HTTP writes are forwarded to the leader
+		return nil, status.Errorf(codes.FailedPrecondition, "%s, send them to %s", errFollower.Error(), g.s.leaderURL.String())
+	}
+
+	events := make([]json.RawMessage, len(req.Payloads))
+	for i, p := range req.Payloads {
+		events[i] = p
+	}
+
+	batch := payloadsBatch(events)
+	batch.IdempotencyKey = req.IdempotencyKey
+
+	for _, e := range req.Events {
+		batch.Events = append(batch.Events, publishEvent{
+			Payload:        e.Payload,
+			Headers:        e.Headers,
+			Key:            e.Key,
+			ContentType:    e.ContentType,
+			IdempotencyKey: e.IdempotencyKey,
+		})
+	}
+
+	for i, e := range batch.Events {
+		if !json.Valid(e.Payload) {
+			return nil, status.Errorf(codes.InvalidArgument, "payload %d is not valid JSON", i)
+		}
+	}
+
+	err = batch.validate()
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	ids, err := g.s.appendEvents(topic, batch)
+	if err != nil {
+		g.s.log.Error(err, "could not store events", "topic", topic)
+		return nil, grpcError(err)
+	}
+
+	return &eventbufferpb.PublishResponse{Ids: ids}, nil
+}
+
+func (g *grpcServer) Poll(ctx context.Context, req *eventbufferpb.PollRequest) (*eventbufferpb.PollResponse, error) {
+	topic, err := grpcTopic(req.Topic)
+	if err != nil {
+		return nil, err
+	}
+
+	err = g.authorize(ctx, ScopeRead, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = 100
+	}
+
+	if limit > maxLimit {
+		return nil, status.Errorf(codes.InvalidArgument, "requested limit %d is larger than allowed %d", limit, maxLimit)
+	}
+
+	after, err := g.s.startPosition(topic, req.After, req.Group)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	pollCtx, done := context.WithTimeout(ctx, pollTimeout)
+	defer done()
+
+	events, _, err := g.s.waitForEvents(pollCtx, topic, after, "", limit, nil)
+	if err == context.DeadlineExceeded && ctx.Err() == nil {
+		// poll timed out without new events
+		return &eventbufferpb.PollResponse{}, nil
+	}
+
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return &eventbufferpb.PollResponse{Events: toProtoEvents(events)}, nil
+}
+
+func (g *grpcServer) Subscribe(req *eventbufferpb.SubscribeRequest, stream eventbufferpb.EventBuffer_SubscribeServer) error {
+	topic, err := grpcTopic(req.Topic)
+	if err != nil {
+		return err
+	}
+
+	err = g.authorize(stream.Context(), ScopeRead, topic)
+	if err != nil {
+		return err
+	}
+
+	batchSize := int(req.BatchSize)
+	if batchSize == 0 || batchSize > maxLimit {
+		batchSize = maxLimit
+	}
+
+	after, err := g.s.startPosition(topic, req.After, req.Group)
+	if err != nil {
+		return grpcError(err)
+	}
+
+	var sendErr error
+	err = g.s.subscribe(stream.Context(), topic, after, batchSize, func(events []event) bool {
+		sendErr = stream.Send(&eventbufferpb.SubscribeResponse{Events: toProtoEvents(events)})
+		return sendErr == nil
+	})
+
+	if err != nil {
+		return grpcError(err)
+	}
+
+	if sendErr != nil {
+		return sendErr
+	}
+
+	return stream.Context().Err()
+}
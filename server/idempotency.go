@@ -0,0 +1,113 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/draganm/bolted"
+	"github.com/draganm/bolted/dbpath"
+)
+
+// idempotencyKeysPath holds one map per topic, mapping idempotency keys
+// of published batches and events to the IDs assigned to them.
+// Batch and event keys are stored with the prefix of their scope, so the same key can identify a batch and an event.
+var idempotencyKeysPath = dbpath.ToPath("idempotency-keys")
+
+// Scopes of idempotency keys.
+const (
+	idempotencyScopeBatch = "batch"
+	idempotencyScopeEvent = "event"
+)
+
+const maxIdempotencyKeyLength = 200
+
+// DefaultIdempotencyWindow is how long idempotency keys are remembered by default.
+const DefaultIdempotencyWindow = 24 * time.Hour
+
+type idempotencyRecord struct {
+	IDs     []string  `json:"ids"`
+	Expires time.Time `json:"expires"`
+}
+
+func validateIdempotencyKey(key string) error {
+	if len(key) > maxIdempotencyKeyLength {
+		return fmt.Errorf("idempotency key is longer than %d bytes", maxIdempotencyKeyLength)
+	}
+	return nil
+}
+
+func topicIdempotencyKeysPath(topic string) dbpath.Path {
+	return idempotencyKeysPath.Append(topic)
+}
+
+func idempotencyRecordPath(topic, scope, key string) dbpath.Path {
+	return topicIdempotencyKeysPath(topic).Append(scope + ":" + key)
+}
+
+// getIdempotencyRecord returns the record stored for the key of the scope, unless it has expired.
+func getIdempotencyRecord(tx bolted.SugaredReadTx, topic, scope, key string, now time.Time) (idempotencyRecord, bool, error) {
+	rec := idempotencyRecord{}
+	p := idempotencyRecordPath(topic, scope, key)
+	if !tx.Exists(p) {
+		return rec, false, nil
+	}
+
+	err := json.Unmarshal(tx.Get(p), &rec)
+	if err != nil {
+		return rec, false, fmt.Errorf("could not unmarshal idempotency record %s: %w", key, err)
+	}
+
+	if !rec.Expires.After(now) {
+		return rec, false, nil
+	}
+
+	return rec, true, nil
+}
+
+func putIdempotencyRecord(tx bolted.SugaredWriteTx, topic, scope, key string, rec idempotencyRecord) error {
+	p := topicIdempotencyKeysPath(topic)
+	if !tx.Exists(p) {
+		tx.CreateMap(p)
+	}
+
+	d, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("could not marshal idempotency record: %w", err)
+	}
+
+	tx.Put(idempotencyRecordPath(topic, scope, key), d)
+	return nil
+}
+
+// pruneIdempotencyKeys deletes expired idempotency records of the topic.
+func (s Server) pruneIdempotencyKeys(topic string, now time.Time) error {
+	return bolted.SugaredWrite(s.db, func(tx bolted.SugaredWriteTx) error {
+		p := topicIdempotencyKeysPath(topic)
+		if !tx.Exists(p) {
+			return nil
+		}
+
+		toDelete := []string{}
+		for it := tx.Iterator(p); !it.IsDone(); it.Next() {
+			rec := idempotencyRecord{}
+			err := json.Unmarshal(it.GetValue(), &rec)
+			if err != nil {
+				return fmt.Errorf("could not unmarshal idempotency record %s: %w", it.GetKey(), err)
+			}
+			if !rec.Expires.After(now) {
+				toDelete = append(toDelete, it.GetKey())
+			}
+		}
+
+		for _, key := range toDelete {
+			tx.Delete(p.Append(key))
+		}
+
+		if len(toDelete) > 0 {
+			s.log.Info("pruned idempotency keys", "topic", topic, "count", len(toDelete))
+		}
+
+		return nil
+	})
+}
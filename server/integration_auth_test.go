@@ -0,0 +1,124 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/draganm/event-buffer/client"
+	"github.com/draganm/event-buffer/eventbufferpb"
+	"github.com/draganm/event-buffer/server"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const testTokenSecret = "0123456789abcdef0123456789abcdef"
+
+func initializeAuthSteps(ctx *godog.ScenarioContext) {
+	ctx.Step(`^a server with the API keys$`, aServerWithTheAPIKeys)
+	ctx.Step(`^a server verifying signed tokens$`, aServerVerifyingSignedTokens)
+	ctx.Step(`^I use the token "([^"]*)"$`, iUseTheToken)
+	ctx.Step(`^I use a signed token granting "([^"]*)"$`, iUseASignedTokenGranting)
+	ctx.Step(`^I try to create the topic "([^"]*)"$`, iTryToCreateTheTopic)
+	ctx.Step(`^the request should have been rejected with status (\d+)$`, theRequestShouldHaveBeenRejectedWithStatus)
+	ctx.Step(`^I try to publish "([^"]*)" over gRPC$`, iTryToPublishOverGRPC)
+	ctx.Step(`^the gRPC request should have been rejected as unauthenticated$`, theGRPCRequestShouldHaveBeenRejectedAsUnauthenticated)
+}
+
+func aServerWithTheAPIKeys(ctx context.Context, keys *godog.DocString) error {
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		os.RemoveAll(dir)
+	}()
+
+	fileName := filepath.Join(dir, "keys.json")
+	err = os.WriteFile(fileName, []byte(keys.Content), 0600)
+	if err != nil {
+		return err
+	}
+
+	apiKeys, err := server.LoadAPIKeys(fileName)
+	if err != nil {
+		return err
+	}
+
+	return startServer(ctx, server.WithAuthenticators(apiKeys))
+}
+
+func aServerVerifyingSignedTokens(ctx context.Context) error {
+	return startServer(ctx, server.WithAuthenticators(server.NewHMACTokens([]byte(testTokenSecret))))
+}
+
+func iUseTheToken(ctx context.Context, token string) error {
+	s := getState(ctx)
+	cl, err := client.New(s.serverBaseURL, client.WithToken(token))
+	if err != nil {
+		return err
+	}
+	s.client = cl
+	return nil
+}
+
+func iUseASignedTokenGranting(ctx context.Context, grants string) error {
+	p := server.Principal{Name: "test"}
+	for _, gs := range strings.Split(grants, ",") {
+		g, err := server.ParseGrant(gs)
+		if err != nil {
+			return err
+		}
+		p.Grants = append(p.Grants, g)
+	}
+
+	token, err := server.NewHMACTokens([]byte(testTokenSecret)).Sign(p, time.Now().Add(time.Hour))
+	if err != nil {
+		return err
+	}
+
+	return iUseTheToken(ctx, token)
+}
+
+func iTryToCreateTheTopic(ctx context.Context, name string) error {
+	s := getState(ctx)
+	s.sendErr = s.client.CreateTopic(ctx, name)
+	return nil
+}
+
+func theRequestShouldHaveBeenRejectedWithStatus(ctx context.Context, code int) error {
+	s := getState(ctx)
+	se := &client.StatusError{}
+	if !errors.As(s.sendErr, &se) {
+		return fmt.Errorf("expected request to fail with status %d, got %v", code, s.sendErr)
+	}
+	if se.StatusCode != code {
+		return fmt.Errorf("expected status %d, got %d", code, se.StatusCode)
+	}
+	return nil
+}
+
+func iTryToPublishOverGRPC(ctx context.Context, evt string) error {
+	s := getState(ctx)
+	d, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	_, s.sendErr = s.grpcClient.Publish(ctx, &eventbufferpb.PublishRequest{Payloads: [][]byte{d}})
+	return nil
+}
+
+func theGRPCRequestShouldHaveBeenRejectedAsUnauthenticated(ctx context.Context) error {
+	s := getState(ctx)
+	if status.Code(s.sendErr) != codes.Unauthenticated {
+		return fmt.Errorf("expected unauthenticated error, got %v", s.sendErr)
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package server_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cucumber/godog"
+	"github.com/draganm/bolted"
+	"github.com/draganm/bolted/dbpath"
+	"github.com/draganm/event-buffer/client"
+	"github.com/draganm/event-buffer/server/testrig"
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+)
+
+func initializeBackupSteps(ctx *godog.ScenarioContext) {
+	ctx.Step(`^I restore a backup of the server$`, iRestoreABackupOfTheServer)
+	ctx.Step(`^I try to restore a backup of the server with a modified event$`, iTryToRestoreABackupOfTheServerWithAModifiedEvent)
+	ctx.Step(`^I try to restore a backup of the server with swapped events$`, iTryToRestoreABackupOfTheServerWithSwappedEvents)
+	ctx.Step(`^I try to restore a truncated backup of the server$`, iTryToRestoreATruncatedBackupOfTheServer)
+	ctx.Step(`^restoring should have failed with "([^"]*)"$`, restoringShouldHaveFailedWith)
+	ctx.Step(`^the restored database should have the same content as the backed up database$`, theRestoredDatabaseShouldHaveTheSameContentAsTheBackedUpDatabase)
+}
+
+// backupLines returns the uncompressed lines of a backup of the server.
+func backupLines(ctx context.Context) ([]string, error) {
+	s := getState(ctx)
+
+	buf := &bytes.Buffer{}
+	err := s.server.Backup(buf)
+	if err != nil {
+		return nil, fmt.Errorf("could not create backup: %w", err)
+	}
+
+	gz, err := gzip.NewReader(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := io.ReadAll(gz)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.SplitAfter(string(d), "\n")
+	// drop the empty string after the last newline
+	return lines[:len(lines)-1], nil
+}
+
+// compressBackup compresses the lines, replacing the trailer with one matching the lines if fixTrailer is set.
+func compressBackup(lines []string, fixTrailer bool) (io.Reader, error) {
+	if fixTrailer {
+		h := sha256.New()
+		for _, l := range lines[:len(lines)-1] {
+			h.Write([]byte(l))
+		}
+		trailer, err := json.Marshal(map[string]any{"end": true, "entries": len(lines) - 2, "sha256": hex.EncodeToString(h.Sum(nil))})
+		if err != nil {
+			return nil, err
+		}
+		lines[len(lines)-1] = string(trailer) + "\n"
+	}
+
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	for _, l := range lines {
+		_, err := gz.Write([]byte(l))
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := gz.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// eventLines returns the indices of the lines holding events of the default topic.
+func eventLines(lines []string) []int {
+	indices := []int{}
+	for i, l := range lines {
+		if strings.HasPrefix(l, `{"path":["events",`) {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+func restoreBackup(ctx context.Context, backup io.Reader) error {
+	s := getState(ctx)
+
+	rig, err := testrig.StartServerFromBackup(ctx, logr.FromContextOrDiscard(ctx), backup)
+	if err != nil {
+		s.restoreErr = err
+		return nil
+	}
+
+	cl, err := client.New(rig.URL)
+	if err != nil {
+		return fmt.Errorf("could not create client: %w", err)
+	}
+
+	s.client = cl
+	s.serverBaseURL = rig.URL
+	s.grpcAddr = rig.GRPCAddr
+	s.server = rig.Server
+	s.db = rig.DB
+
+	return nil
+}
+
+func iRestoreABackupOfTheServer(ctx context.Context) error {
+	s := getState(ctx)
+
+	buf := &bytes.Buffer{}
+	err := s.server.Backup(buf)
+	if err != nil {
+		return fmt.Errorf("could not create backup: %w", err)
+	}
+
+	s.backedUpDB = s.db
+	err = restoreBackup(ctx, buf)
+	if err != nil {
+		return err
+	}
+
+	if s.restoreErr != nil {
+		return fmt.Errorf("could not restore backup: %w", s.restoreErr)
+	}
+
+	return nil
+}
+
+func iTryToRestoreABackupOfTheServerWithAModifiedEvent(ctx context.Context) error {
+	lines, err := backupLines(ctx)
+	if err != nil {
+		return err
+	}
+
+	events := eventLines(lines)
+	if len(events) == 0 {
+		return fmt.Errorf("backup has no events")
+	}
+
+	e := map[string]any{}
+	err = json.Unmarshal([]byte(lines[events[0]]), &e)
+	if err != nil {
+		return err
+	}
+	e["value"] = "bW9kaWZpZWQ="
+	d, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	lines[events[0]] = string(d) + "\n"
+
+	backup, err := compressBackup(lines, false)
+	if err != nil {
+		return err
+	}
+
+	return restoreBackup(ctx, backup)
+}
+
+func iTryToRestoreABackupOfTheServerWithSwappedEvents(ctx context.Context) error {
+	lines, err := backupLines(ctx)
+	if err != nil {
+		return err
+	}
+
+	events := eventLines(lines)
+	if len(events) < 2 {
+		return fmt.Errorf("expected at least 2 events in the backup, got %d", len(events))
+	}
+
+	lines[events[0]], lines[events[1]] = lines[events[1]], lines[events[0]]
+
+	backup, err := compressBackup(lines, true)
+	if err != nil {
+		return err
+	}
+
+	return restoreBackup(ctx, backup)
+}
+
+func iTryToRestoreATruncatedBackupOfTheServer(ctx context.Context) error {
+	lines, err := backupLines(ctx)
+	if err != nil {
+		return err
+	}
+
+	backup, err := compressBackup(lines[:len(lines)-1], false)
+	if err != nil {
+		return err
+	}
+
+	return restoreBackup(ctx, backup)
+}
+
+func restoringShouldHaveFailedWith(ctx context.Context, message string) error {
+	s := getState(ctx)
+	if s.restoreErr == nil {
+		return fmt.Errorf("expected restoring to fail")
+	}
+	if !strings.Contains(s.restoreErr.Error(), message) {
+		return fmt.Errorf("expected error containing %q, got %q", message, s.restoreErr.Error())
+	}
+	return nil
+}
+
+// databaseContent returns the values of the database by their paths, with an empty value for each map.
+func databaseContent(db bolted.Database) (map[string]string, error) {
+	content := map[string]string{}
+	err := bolted.SugaredRead(db, func(tx bolted.SugaredReadTx) error {
+		var walk func(p dbpath.Path)
+		walk = func(p dbpath.Path) {
+			for it := tx.Iterator(p); !it.IsDone(); it.Next() {
+				cp := p.Append(it.GetKey())
+				if tx.IsMap(cp) {
+					content[cp.String()] = ""
+					walk(cp)
+					continue
+				}
+				content[cp.String()] = string(it.GetValue())
+			}
+		}
+		walk(dbpath.ToPath())
+		return nil
+	})
+	return content, err
+}
+
+func theRestoredDatabaseShouldHaveTheSameContentAsTheBackedUpDatabase(ctx context.Context) error {
+	s := getState(ctx)
+
+	expected, err := databaseContent(s.backedUpDB)
+	if err != nil {
+		return fmt.Errorf("could not read the backed up database: %w", err)
+	}
+
+	restored, err := databaseContent(s.db)
+	if err != nil {
+		return fmt.Errorf("could not read the restored database: %w", err)
+	}
+
+	d := cmp.Diff(expected, restored)
+	if d != "" {
+		return fmt.Errorf("unexpected content of the restored database:\n%s", d)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cucumber/godog"
+	"github.com/draganm/event-buffer/client"
+	"github.com/draganm/event-buffer/server"
+	"github.com/google/go-cmp/cmp"
+)
+
+func initializeCompactionSteps(ctx *godog.ScenarioContext) {
+	ctx.Step(`^a compacted topic named "([^"]*)"$`, aCompactedTopicNamed)
+	ctx.Step(`^a server compacting topics by default$`, aServerCompactingTopicsByDefault)
+	ctx.Step(`^I disable compaction of the topic "([^"]*)"$`, iDisableCompactionOfTheTopic)
+	ctx.Step(`^I send the keyed events "([^"]*)" to the topic "([^"]*)"$`, iSendTheKeyedEventsToTheTopic)
+	ctx.Step(`^I send a tombstone for the key "([^"]*)" to the topic "([^"]*)"$`, iSendATombstoneForTheKeyToTheTopic)
+	ctx.Step(`^the topic "([^"]*)" should contain "([^"]*)"$`, theTopicShouldContain)
+	ctx.Step(`^the topic "([^"]*)" should be compacted$`, theTopicShouldBeCompacted)
+	ctx.Step(`^the topic "([^"]*)" should not be compacted$`, theTopicShouldNotBeCompacted)
+}
+
+func aCompactedTopicNamed(ctx context.Context, name string) error {
+	s := getState(ctx)
+	compaction := true
+	return s.client.ConfigureTopic(ctx, name, client.TopicConfig{Compaction: &compaction})
+}
+
+func aServerCompactingTopicsByDefault(ctx context.Context) error {
+	return startServer(ctx, server.WithCompaction(true))
+}
+
+func iDisableCompactionOfTheTopic(ctx context.Context, name string) error {
+	s := getState(ctx)
+	compaction := false
+	return s.client.ConfigureTopic(ctx, name, client.TopicConfig{Compaction: &compaction})
+}
+
+// iSendTheKeyedEventsToTheTopic sends events given as key=payload, or just payload for events without a key.
+func iSendTheKeyedEventsToTheTopic(ctx context.Context, events, topic string) error {
+	s := getState(ctx)
+	batch := client.Batch{}
+	for _, e := range strings.Split(events, ",") {
+		key, payload, found := strings.Cut(e, "=")
+		if !found {
+			key, payload = "", e
+		}
+		batch.Events = append(batch.Events, client.BatchEvent{Key: key, Payload: payload})
+	}
+	_, err := s.client.Topic(topic).SendBatch(ctx, batch)
+	return err
+}
+
+func iSendATombstoneForTheKeyToTheTopic(ctx context.Context, key, topic string) error {
+	s := getState(ctx)
+	_, err := s.client.Topic(topic).SendBatch(ctx, client.Batch{Events: []client.BatchEvent{{Key: key}}})
+	return err
+}
+
+func theTopicShouldContain(ctx context.Context, topic, expected string) error {
+	s := getState(ctx)
+	events, err := s.client.Topic(topic).ReadEvents(ctx, "", 100)
+	if err != nil {
+		return fmt.Errorf("could not read events: %w", err)
+	}
+
+	actual := []string{}
+	for _, e := range events {
+		payload := string(e.Payload)
+		str := ""
+		if payload != "null" && json.Unmarshal(e.Payload, &str) == nil {
+			payload = str
+		}
+		if e.Key != "" {
+			payload = e.Key + "=" + payload
+		}
+		actual = append(actual, payload)
+	}
+
+	d := cmp.Diff(strings.Split(expected, ","), actual)
+	if d != "" {
+		return fmt.Errorf("unexpected events:\n%s", d)
+	}
+	return nil
+}
+
+func theTopicShouldBeCompacted(ctx context.Context, topic string) error {
+	return topicCompactionShouldBe(ctx, topic, true)
+}
+
+func theTopicShouldNotBeCompacted(ctx context.Context, topic string) error {
+	return topicCompactionShouldBe(ctx, topic, false)
+}
+
+func topicCompactionShouldBe(ctx context.Context, topic string, expected bool) error {
+	s := getState(ctx)
+	info, err := s.client.GetTopic(ctx, topic)
+	if err != nil {
+		return fmt.Errorf("could not get topic: %w", err)
+	}
+	if info.Compaction != expected {
+		return fmt.Errorf("expected compaction of topic %s to be %t", topic, expected)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/cucumber/godog"
+	"github.com/draganm/event-buffer/client"
+)
+
+func initializeEraseSteps(ctx *godog.ScenarioContext) {
+	ctx.Step(`^I delete the second event because "([^"]*)"$`, iDeleteTheSecondEventBecause)
+	ctx.Step(`^I delete the events matching "([^"]*)" because "([^"]*)"$`, iDeleteTheEventsMatchingBecause)
+	ctx.Step(`^I redact the field "([^"]*)" of the events matching "([^"]*)" because "([^"]*)"$`, iRedactTheFieldOfTheEventsMatchingBecause)
+	ctx.Step(`^I get the first event$`, iGetTheFirstEvent)
+	ctx.Step(`^the event should have the JSON payload$`, theEventShouldHaveTheJSONPayload)
+	ctx.Step(`^the audit log should have a "([^"]*)" record with the reason "([^"]*)" affecting (\d+) events?$`, theAuditLogShouldHaveARecordWithTheReasonAffectingEvents)
+	ctx.Step(`^I try to delete the first event without a reason$`, iTryToDeleteTheFirstEventWithoutAReason)
+	ctx.Step(`^I try to delete the first event because "([^"]*)"$`, iTryToDeleteTheFirstEventBecause)
+	ctx.Step(`^the audit log should name the principal "([^"]*)"$`, theAuditLogShouldNameThePrincipal)
+	ctx.Step(`^the audit log should not contain "([^"]*)"$`, theAuditLogShouldNotContain)
+	ctx.Step(`^reading the audit log in pages of (\d+) records? should return the reasons "([^"]*)"$`, readingTheAuditLogInPagesOfRecordsShouldReturnTheReasons)
+}
+
+func readingTheAuditLogInPagesOfRecordsShouldReturnTheReasons(ctx context.Context, pageSize int, expected string) error {
+	s := getState(ctx)
+	reasons := []string{}
+	lastID := ""
+	for {
+		records, err := s.client.AuditRecordsAfter(ctx, lastID, pageSize)
+		if err != nil {
+			return err
+		}
+		if len(records) > pageSize {
+			return fmt.Errorf("expected at most %d audit records, got %d", pageSize, len(records))
+		}
+		if len(records) == 0 {
+			break
+		}
+		for _, rec := range records {
+			reasons = append(reasons, rec.Reason)
+		}
+		lastID = records[len(records)-1].ID
+	}
+
+	if strings.Join(reasons, ",") != expected {
+		return fmt.Errorf("expected reasons %q, got %q", expected, strings.Join(reasons, ","))
+	}
+	return nil
+}
+
+func iDeleteTheSecondEventBecause(ctx context.Context, reason string) error {
+	s := getState(ctx)
+	_, err := s.client.DeleteEvents(ctx, client.Selection{IDs: []string{s.published[1].ID}, Reason: reason})
+	return err
+}
+
+func iDeleteTheEventsMatchingBecause(ctx context.Context, filter, reason string) error {
+	s := getState(ctx)
+	_, err := s.client.DeleteEvents(ctx, client.Selection{Filters: []string{filter}, Reason: reason})
+	return err
+}
+
+func iRedactTheFieldOfTheEventsMatchingBecause(ctx context.Context, field, filter, reason string) error {
+	s := getState(ctx)
+	_, err := s.client.RedactEvents(ctx, client.Selection{Filters: []string{filter}, Reason: reason}, []string{field}, nil)
+	return err
+}
+
+func iGetTheFirstEvent(ctx context.Context) error {
+	s := getState(ctx)
+	e, err := s.client.GetEvent(ctx, s.published[0].ID)
+	if err != nil {
+		return err
+	}
+	s.polledEvent = e
+	return nil
+}
+
+func theEventShouldHaveTheJSONPayload(ctx context.Context, expected *godog.DocString) error {
+	s := getState(ctx)
+
+	var expectedPayload, payload any
+	err := json.Unmarshal([]byte(expected.Content), &expectedPayload)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(s.polledEvent.Payload, &payload)
+	if err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(expectedPayload, payload) {
+		return fmt.Errorf("expected payload %s, got %s", expected.Content, string(s.polledEvent.Payload))
+	}
+
+	return nil
+}
+
+func theAuditLogShouldHaveARecordWithTheReasonAffectingEvents(ctx context.Context, operation, reason string, affected int) error {
+	s := getState(ctx)
+	records, err := s.client.AuditRecords(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, rec := range records {
+		if rec.Operation == operation && rec.Reason == reason {
+			if len(rec.Affected) != affected {
+				return fmt.Errorf("expected %d affected events, got %d", affected, len(rec.Affected))
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no %s record with the reason %q in %v", operation, reason, records)
+}
+
+func iTryToDeleteTheFirstEventWithoutAReason(ctx context.Context) error {
+	s := getState(ctx)
+	_, s.sendErr = s.client.DeleteEvents(ctx, client.Selection{IDs: []string{s.published[0].ID}})
+	return nil
+}
+
+func iTryToDeleteTheFirstEventBecause(ctx context.Context, reason string) error {
+	s := getState(ctx)
+	_, s.sendErr = s.client.DeleteEvents(ctx, client.Selection{IDs: []string{s.published[0].ID}, Reason: reason})
+	return nil
+}
+
+func theAuditLogShouldNameThePrincipal(ctx context.Context, principal string) error {
+	s := getState(ctx)
+	records, err := s.client.AuditRecords(ctx)
+	if err != nil {
+		return err
+	}
+	if len(records) != 1 {
+		return fmt.Errorf("expected one audit record, got %d", len(records))
+	}
+	if records[0].Principal != principal {
+		return fmt.Errorf("expected principal %q, got %q", principal, records[0].Principal)
+	}
+	return nil
+}
+
+func theAuditLogShouldNotContain(ctx context.Context, text string) error {
+	s := getState(ctx)
+	records, err := s.client.AuditRecords(ctx)
+	if err != nil {
+		return err
+	}
+
+	d, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+
+	if strings.Contains(string(d), text) {
+		return fmt.Errorf("audit log contains %q: %s", text, string(d))
+	}
+
+	return nil
+}
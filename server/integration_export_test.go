@@ -0,0 +1,181 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cucumber/godog"
+	"github.com/draganm/event-buffer/client"
+	"github.com/draganm/event-buffer/server"
+	"github.com/google/go-cmp/cmp"
+)
+
+func initializeExportSteps(ctx *godog.ScenarioContext) {
+	ctx.Step(`^reading the default topic without waiting should return "([^"]*)"$`, readingTheDefaultTopicWithoutWaitingShouldReturn)
+	ctx.Step(`^reading the topic "([^"]*)" without waiting should return no events$`, readingTheTopicWithoutWaitingShouldReturnNoEvents)
+	ctx.Step(`^I export the events after the first one up to the second one$`, iExportTheEventsAfterTheFirstOneUpToTheSecondOne)
+	ctx.Step(`^I export the events$`, iExportTheEvents)
+	ctx.Step(`^the exported events should be "([^"]*)"$`, theExportedEventsShouldBe)
+	ctx.Step(`^I import the exported events into a new server$`, iImportTheExportedEventsIntoANewServer)
+	ctx.Step(`^I import the exported events$`, iImportTheExportedEvents)
+	ctx.Step(`^I import the events$`, iImportTheEvents)
+	ctx.Step(`^the events of the new server should have the exported IDs$`, theEventsOfTheNewServerShouldHaveTheExportedIDs)
+	ctx.Step(`^importing should have failed with "([^"]*)"$`, importingShouldHaveFailedWith)
+}
+
+func payloadStrings(events []client.Event) ([]string, error) {
+	payloads := []string{}
+	for _, e := range events {
+		var p string
+		err := json.Unmarshal(e.Payload, &p)
+		if err != nil {
+			return nil, err
+		}
+		payloads = append(payloads, p)
+	}
+	return payloads, nil
+}
+
+func readingTheDefaultTopicWithoutWaitingShouldReturn(ctx context.Context, expected string) error {
+	s := getState(ctx)
+	events, err := s.client.ReadEvents(ctx, "", 100)
+	if err != nil {
+		return err
+	}
+	payloads, err := payloadStrings(events)
+	if err != nil {
+		return err
+	}
+	d := cmp.Diff(strings.Split(expected, ","), payloads)
+	if d != "" {
+		return fmt.Errorf("unexpected events:\n%s", d)
+	}
+	return nil
+}
+
+func readingTheTopicWithoutWaitingShouldReturnNoEvents(ctx context.Context, topic string) error {
+	s := getState(ctx)
+	events, err := s.client.Topic(topic).ReadEvents(ctx, "", 100)
+	if err != nil {
+		return err
+	}
+	if len(events) != 0 {
+		return fmt.Errorf("expected no events, got %d", len(events))
+	}
+	return nil
+}
+
+func exportEvents(ctx context.Context, r server.EventRange) error {
+	s := getState(ctx)
+	s.exported = nil
+	return server.ExportEvents(s.db, nil, r, func(e server.ExportedEvent) error {
+		s.exported = append(s.exported, e)
+		return nil
+	})
+}
+
+func iExportTheEventsAfterTheFirstOneUpToTheSecondOne(ctx context.Context) error {
+	s := getState(ctx)
+	if len(s.published) < 2 {
+		return fmt.Errorf("expected at least 2 published events, got %d", len(s.published))
+	}
+	return exportEvents(ctx, server.EventRange{AfterID: s.published[0].ID, UntilID: s.published[1].ID})
+}
+
+func iExportTheEvents(ctx context.Context) error {
+	return exportEvents(ctx, server.EventRange{})
+}
+
+func theExportedEventsShouldBe(ctx context.Context, expected string) error {
+	s := getState(ctx)
+	events := []client.Event{}
+	for _, e := range s.exported {
+		events = append(events, client.Event{ID: e.ID, Payload: e.Payload})
+	}
+	payloads, err := payloadStrings(events)
+	if err != nil {
+		return err
+	}
+	d := cmp.Diff(strings.Split(expected, ","), payloads)
+	if d != "" {
+		return fmt.Errorf("unexpected exported events:\n%s", d)
+	}
+	return nil
+}
+
+func importEvents(ctx context.Context, events []server.ExportedEvent) {
+	s := getState(ctx)
+	_, s.importErr = s.server.ImportEvents(func() (server.ExportedEvent, bool, error) {
+		if len(events) == 0 {
+			return server.ExportedEvent{}, false, nil
+		}
+		e := events[0]
+		events = events[1:]
+		return e, true, nil
+	})
+}
+
+func iImportTheExportedEventsIntoANewServer(ctx context.Context) error {
+	err := startServer(ctx)
+	if err != nil {
+		return err
+	}
+	return iImportTheExportedEvents(ctx)
+}
+
+func iImportTheExportedEvents(ctx context.Context) error {
+	s := getState(ctx)
+	importEvents(ctx, s.exported)
+	return nil
+}
+
+func iImportTheEvents(ctx context.Context, doc *godog.DocString) error {
+	s := getState(ctx)
+	events := []server.ExportedEvent{}
+	dec := json.NewDecoder(strings.NewReader(doc.Content))
+	for dec.More() {
+		e := server.ExportedEvent{Topic: server.DefaultTopic}
+		err := dec.Decode(&e)
+		if err != nil {
+			return err
+		}
+		events = append(events, e)
+	}
+	importEvents(ctx, events)
+	if s.importErr != nil {
+		return fmt.Errorf("could not import events: %w", s.importErr)
+	}
+	return nil
+}
+
+func theEventsOfTheNewServerShouldHaveTheExportedIDs(ctx context.Context) error {
+	s := getState(ctx)
+	if s.importErr != nil {
+		return fmt.Errorf("could not import events: %w", s.importErr)
+	}
+
+	exported := s.exported
+	err := exportEvents(ctx, server.EventRange{})
+	if err != nil {
+		return err
+	}
+
+	d := cmp.Diff(exported, s.exported)
+	if d != "" {
+		return fmt.Errorf("imported events differ from the exported ones:\n%s", d)
+	}
+	return nil
+}
+
+func importingShouldHaveFailedWith(ctx context.Context, message string) error {
+	s := getState(ctx)
+	if s.importErr == nil {
+		return fmt.Errorf("expected importing to fail")
+	}
+	if !strings.Contains(s.importErr.Error(), message) {
+		return fmt.Errorf("expected error containing %q, got %q", message, s.importErr.Error())
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cucumber/godog"
+	"github.com/draganm/event-buffer/client"
+	"github.com/google/go-cmp/cmp"
+)
+
+func initializeFilterSteps(ctx *godog.ScenarioContext) {
+	ctx.Step(`^the events$`, iSendTheJSONEvents)
+	ctx.Step(`^I send the events$`, iSendTheJSONEvents)
+	ctx.Step(`^I poll for the events matching "([^"]*)"$`, iPollForTheEventsMatching)
+	ctx.Step(`^I poll for one event matching "([^"]*)"$`, iPollForOneEventMatching)
+	ctx.Step(`^I poll for the events matching "([^"]*)" after the cursor$`, iPollForTheEventsMatchingAfterTheCursor)
+	ctx.Step(`^I should receive the events with the IDs "([^"]*)"$`, iShouldReceiveTheEventsWithTheIDs)
+	ctx.Step(`^I start polling for the events matching "([^"]*)"$`, iStartPollingForTheEventsMatching)
+	ctx.Step(`^the poll should return the events with the IDs "([^"]*)"$`, thePollShouldReturnTheEventsWithTheIDs)
+}
+
+func iSendTheJSONEvents(ctx context.Context, events *godog.DocString) error {
+	s := getState(ctx)
+	evts := []any{}
+	err := json.Unmarshal([]byte(events.Content), &evts)
+	if err != nil {
+		return fmt.Errorf("could not parse events: %w", err)
+	}
+	s.published, err = s.client.SendEvents(ctx, evts)
+	return err
+}
+
+func pollFiltered(ctx context.Context, after string, limit int, filters string) error {
+	s := getState(ctx)
+	evts, cursor, err := s.client.PollFiltered(ctx, after, limit, strings.Split(filters, ";")...)
+	if err != nil {
+		return err
+	}
+	s.filteredEvents = evts
+	s.cursor = cursor
+	return nil
+}
+
+func iPollForTheEventsMatching(ctx context.Context, filters string) error {
+	return pollFiltered(ctx, "", 100, filters)
+}
+
+func iPollForOneEventMatching(ctx context.Context, filters string) error {
+	return pollFiltered(ctx, "", 1, filters)
+}
+
+func iPollForTheEventsMatchingAfterTheCursor(ctx context.Context, filters string) error {
+	return pollFiltered(ctx, getState(ctx).cursor, 100, filters)
+}
+
+// payloadIDs returns the id properties of the event payloads.
+func payloadIDs(events []client.Event) ([]string, error) {
+	ids := []string{}
+	for _, e := range events {
+		p := struct {
+			ID string `json:"id"`
+		}{}
+		err := json.Unmarshal(e.Payload, &p)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse payload of event %s: %w", e.ID, err)
+		}
+		ids = append(ids, p.ID)
+	}
+	return ids, nil
+}
+
+func iShouldReceiveTheEventsWithTheIDs(ctx context.Context, expected string) error {
+	s := getState(ctx)
+	ids, err := payloadIDs(s.filteredEvents)
+	if err != nil {
+		return err
+	}
+	d := cmp.Diff(strings.Split(expected, ","), ids)
+	if d != "" {
+		return fmt.Errorf("unexpected events:\n%s", d)
+	}
+	return nil
+}
+
+func iStartPollingForTheEventsMatching(ctx context.Context, filters string) error {
+	s := getState(ctx)
+	s.longPollResult = make(chan eventsOrError, 1)
+	go func() {
+		evts, _, err := s.client.PollFiltered(ctx, "", 100, strings.Split(filters, ";")...)
+		if err != nil {
+			s.longPollResult <- eventsOrError{err: fmt.Errorf("failed polling for events: %w", err)}
+			return
+		}
+		ids, err := payloadIDs(evts)
+		s.longPollResult <- eventsOrError{events: ids, err: err}
+	}()
+	return nil
+}
+
+func thePollShouldReturnTheEventsWithTheIDs(ctx context.Context, expected string) error {
+	s := getState(ctx)
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("could not get long poll events: %w", ctx.Err())
+	case res := <-s.longPollResult:
+		if res.err != nil {
+			return fmt.Errorf("long poll failed: %w", res.err)
+		}
+		d := cmp.Diff(strings.Split(expected, ","), res.events)
+		if d != "" {
+			return fmt.Errorf("unexpected poll result:\n%s", d)
+		}
+	}
+	return nil
+}
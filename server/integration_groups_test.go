@@ -0,0 +1,94 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cucumber/godog"
+	"github.com/gofrs/uuid"
+	"github.com/google/go-cmp/cmp"
+)
+
+func initializeGroupSteps(ctx *godog.ScenarioContext) {
+	ctx.Step(`^the consumer group "([^"]*)" polls for one event and commits it$`, theConsumerGroupPollsForOneEventAndCommitsIt)
+	ctx.Step(`^the consumer group "([^"]*)" polls for events$`, theConsumerGroupPollsForEvents)
+	ctx.Step(`^the consumer group should receive "([^"]*)"$`, theConsumerGroupShouldReceive)
+	ctx.Step(`^the committed offset of "([^"]*)" should be the ID of the first event$`, theCommittedOffsetOfShouldBeTheIDOfTheFirstEvent)
+	ctx.Step(`^the consumer group "([^"]*)" commits the (first|second) event$`, theConsumerGroupCommitsTheEvent)
+	ctx.Step(`^the consumer group "([^"]*)" tries to commit (the first event|a random UUIDv4|an ID after the newest event)$`, theConsumerGroupTriesToCommit)
+	ctx.Step(`^the consumer group "([^"]*)" is reset to the first event$`, theConsumerGroupIsResetToTheFirstEvent)
+}
+
+func publishedEventID(s *State, which string) string {
+	if which == "first" {
+		return s.published[0].ID
+	}
+	return s.published[1].ID
+}
+
+func theConsumerGroupCommitsTheEvent(ctx context.Context, group, which string) error {
+	s := getState(ctx)
+	return s.client.CommitOffset(ctx, group, publishedEventID(s, which))
+}
+
+func theConsumerGroupTriesToCommit(ctx context.Context, group, offset string) error {
+	s := getState(ctx)
+	var id string
+	switch offset {
+	case "the first event":
+		id = publishedEventID(s, "first")
+	case "a random UUIDv4":
+		id = uuid.Must(uuid.NewV4()).String()
+	case "an ID after the newest event":
+		id = uuid.Must(uuid.NewV6()).String()
+	}
+	s.sendErr = s.client.CommitOffset(ctx, group, id)
+	return nil
+}
+
+func theConsumerGroupIsResetToTheFirstEvent(ctx context.Context, group string) error {
+	s := getState(ctx)
+	return s.client.ResetOffset(ctx, group, publishedEventID(s, "first"))
+}
+
+func theConsumerGroupPollsForOneEventAndCommitsIt(ctx context.Context, group string) error {
+	s := getState(ctx)
+	evts := []string{}
+	return s.client.PollAndCommit(ctx, group, 1, &evts, func(ids []string) error {
+		s.lastId = ids[len(ids)-1]
+		return nil
+	})
+}
+
+func theConsumerGroupPollsForEvents(ctx context.Context, group string) error {
+	s := getState(ctx)
+	evts := []string{}
+	_, err := s.client.PollForGroupEvents(ctx, group, 100, &evts)
+	if err != nil {
+		return fmt.Errorf("failed polling for events: %w", err)
+	}
+	s.pollResult = evts
+	return nil
+}
+
+func theConsumerGroupShouldReceive(ctx context.Context, expected string) error {
+	s := getState(ctx)
+	d := cmp.Diff(s.pollResult, strings.Split(expected, ","))
+	if d != "" {
+		return fmt.Errorf("unexpected poll result:\n%s", d)
+	}
+	return nil
+}
+
+func theCommittedOffsetOfShouldBeTheIDOfTheFirstEvent(ctx context.Context, group string) error {
+	s := getState(ctx)
+	id, err := s.client.CommittedOffset(ctx, group)
+	if err != nil {
+		return fmt.Errorf("could not get committed offset: %w", err)
+	}
+	if id != s.lastId {
+		return fmt.Errorf("expected committed offset %s, got %s", s.lastId, id)
+	}
+	return nil
+}
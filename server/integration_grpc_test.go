@@ -0,0 +1,121 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/draganm/event-buffer/eventbufferpb"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func initializeGRPCSteps(ctx *godog.ScenarioContext) {
+	ctx.Step(`^I have a gRPC connection$`, iHaveAGRPCConnection)
+	ctx.Step(`^I publish "([^"]*)" over gRPC$`, iPublishOverGRPC)
+	ctx.Step(`^polling over gRPC should return "([^"]*)"$`, pollingOverGRPCShouldReturn)
+	ctx.Step(`^I subscribe over gRPC$`, iSubscribeOverGRPC)
+	ctx.Step(`^the gRPC subscription should deliver "([^"]*)"$`, theGRPCSubscriptionShouldDeliver)
+}
+
+func iHaveAGRPCConnection(ctx context.Context) error {
+	s := getState(ctx)
+	conn, err := grpc.DialContext(ctx, s.grpcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return fmt.Errorf("could not dial grpc: %w", err)
+	}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+	s.grpcClient = eventbufferpb.NewEventBufferClient(conn)
+	return nil
+}
+
+func iPublishOverGRPC(ctx context.Context, evt string) error {
+	s := getState(ctx)
+	d, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	res, err := s.grpcClient.Publish(ctx, &eventbufferpb.PublishRequest{Payloads: [][]byte{d}})
+	if err != nil {
+		return fmt.Errorf("could not publish: %w", err)
+	}
+	if len(res.Ids) != 1 {
+		return fmt.Errorf("expected 1 id, got %d", len(res.Ids))
+	}
+	return nil
+}
+
+func pollingOverGRPCShouldReturn(ctx context.Context, expected string) error {
+	s := getState(ctx)
+	res, err := s.grpcClient.Poll(ctx, &eventbufferpb.PollRequest{})
+	if err != nil {
+		return fmt.Errorf("could not poll: %w", err)
+	}
+	received := []string{}
+	for _, e := range res.Events {
+		var evt string
+		err = json.Unmarshal(e.Payload, &evt)
+		if err != nil {
+			return err
+		}
+		received = append(received, evt)
+	}
+	d := cmp.Diff(received, strings.Split(expected, ","))
+	if d != "" {
+		return fmt.Errorf("unexpected poll result:\n%s", d)
+	}
+	return nil
+}
+
+func iSubscribeOverGRPC(ctx context.Context) error {
+	s := getState(ctx)
+	stream, err := s.grpcClient.Subscribe(ctx, &eventbufferpb.SubscribeRequest{})
+	if err != nil {
+		return fmt.Errorf("could not subscribe: %w", err)
+	}
+	s.grpcEvents = make(chan string, 100)
+	go func() {
+		for {
+			res, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			for _, e := range res.Events {
+				var evt string
+				err = json.Unmarshal(e.Payload, &evt)
+				if err != nil {
+					return
+				}
+				s.grpcEvents <- evt
+			}
+		}
+	}()
+	return nil
+}
+
+func theGRPCSubscriptionShouldDeliver(ctx context.Context, expected string) error {
+	s := getState(ctx)
+	expectedEvents := strings.Split(expected, ",")
+	received := []string{}
+	timeout := time.After(5 * time.Second)
+	for len(received) < len(expectedEvents) {
+		select {
+		case evt := <-s.grpcEvents:
+			received = append(received, evt)
+		case <-timeout:
+			return fmt.Errorf("timed out waiting for events, received %v", received)
+		}
+	}
+	d := cmp.Diff(received, expectedEvents)
+	if d != "" {
+		return fmt.Errorf("unexpected events:\n%s", d)
+	}
+	return nil
+}
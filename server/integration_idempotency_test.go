@@ -0,0 +1,56 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cucumber/godog"
+	"github.com/draganm/event-buffer/client"
+	"github.com/google/go-cmp/cmp"
+)
+
+func initializeIdempotencySteps(ctx *godog.ScenarioContext) {
+	ctx.Step(`^I send the batch "([^"]*)" with the idempotency key "([^"]*)"$`, iSendTheBatchWithTheIdempotencyKey)
+	ctx.Step(`^both sends should have returned the same IDs$`, bothSendsShouldHaveReturnedTheSameIDs)
+	ctx.Step(`^I send the event "([^"]*)" with the idempotency key "([^"]*)" together with "([^"]*)"$`, iSendTheEventWithTheIdempotencyKeyTogetherWith)
+}
+
+func iSendTheBatchWithTheIdempotencyKey(ctx context.Context, evt, key string) error {
+	s := getState(ctx)
+	ids, err := s.client.SendBatch(ctx, client.Batch{
+		IdempotencyKey: key,
+		Events:         []client.BatchEvent{{Payload: evt}},
+	})
+	if err != nil {
+		return fmt.Errorf("could not send batch: %w", err)
+	}
+	s.sentIDs = append(s.sentIDs, ids)
+	return nil
+}
+
+func bothSendsShouldHaveReturnedTheSameIDs(ctx context.Context) error {
+	s := getState(ctx)
+	if len(s.sentIDs) != 2 {
+		return fmt.Errorf("expected 2 sends, got %d", len(s.sentIDs))
+	}
+	d := cmp.Diff(s.sentIDs[0], s.sentIDs[1])
+	if d != "" {
+		return fmt.Errorf("IDs differ:\n%s", d)
+	}
+	return nil
+}
+
+func iSendTheEventWithTheIdempotencyKeyTogetherWith(ctx context.Context, evt, key, other string) error {
+	s := getState(ctx)
+	ids, err := s.client.SendBatch(ctx, client.Batch{
+		Events: []client.BatchEvent{
+			{Payload: evt, IdempotencyKey: key},
+			{Payload: other},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("could not send batch: %w", err)
+	}
+	s.sentIDs = append(s.sentIDs, ids)
+	return nil
+}
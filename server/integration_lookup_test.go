@@ -0,0 +1,120 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cucumber/godog"
+	"github.com/draganm/event-buffer/client"
+	"github.com/gofrs/uuid"
+	"github.com/google/go-cmp/cmp"
+)
+
+func initializeLookupSteps(ctx *godog.ScenarioContext) {
+	ctx.Step(`^I get the second event$`, iGetTheSecondEvent)
+	ctx.Step(`^I get the event sent to the topic "([^"]*)"$`, iGetTheEventSentToTheTopic)
+	ctx.Step(`^the event should have the payload "([^"]*)"$`, theEventShouldHaveThePayload)
+	ctx.Step(`^I try to get an event that doesn't exist$`, iTryToGetAnEventThatDoesntExist)
+	ctx.Step(`^the event should not have been found$`, theEventShouldNotHaveBeenFound)
+	ctx.Step(`^I get the third and the first event and an event that doesn't exist$`, iGetTheThirdAndTheFirstEventAndAnEventThatDoesntExist)
+	ctx.Step(`^the events should be "([^"]*)"$`, theEventsShouldBe)
+	ctx.Step(`^one event should be missing$`, oneEventShouldBeMissing)
+}
+
+func iGetTheSecondEvent(ctx context.Context) error {
+	s := getState(ctx)
+	e, err := s.client.GetEvent(ctx, s.published[1].ID)
+	if err != nil {
+		return err
+	}
+	s.polledEvent = e
+	return nil
+}
+
+func iGetTheEventSentToTheTopic(ctx context.Context, topic string) error {
+	s := getState(ctx)
+	e, err := s.client.Topic(topic).GetEvent(ctx, s.published[0].ID)
+	if err != nil {
+		return err
+	}
+	s.polledEvent = e
+	return nil
+}
+
+func theEventShouldHaveThePayload(ctx context.Context, expected string) error {
+	s := getState(ctx)
+	var payload string
+	err := json.Unmarshal(s.polledEvent.Payload, &payload)
+	if err != nil {
+		return err
+	}
+	if payload != expected {
+		return fmt.Errorf("expected payload %q, got %q", expected, payload)
+	}
+	return nil
+}
+
+func missingEventID() (string, error) {
+	id, err := uuid.NewV6()
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+func iTryToGetAnEventThatDoesntExist(ctx context.Context) error {
+	s := getState(ctx)
+	id, err := missingEventID()
+	if err != nil {
+		return err
+	}
+	_, s.sendErr = s.client.GetEvent(ctx, id)
+	return nil
+}
+
+func theEventShouldNotHaveBeenFound(ctx context.Context) error {
+	s := getState(ctx)
+	if !errors.Is(s.sendErr, client.ErrNotFound) {
+		return fmt.Errorf("expected not found error, got %v", s.sendErr)
+	}
+	return nil
+}
+
+func iGetTheThirdAndTheFirstEventAndAnEventThatDoesntExist(ctx context.Context) error {
+	s := getState(ctx)
+	id, err := missingEventID()
+	if err != nil {
+		return err
+	}
+	events, missing, err := s.client.GetEvents(ctx, []string{s.published[2].ID, s.published[0].ID, id})
+	if err != nil {
+		return err
+	}
+	s.filteredEvents = events
+	s.missing = missing
+	return nil
+}
+
+func theEventsShouldBe(ctx context.Context, expected string) error {
+	s := getState(ctx)
+	payloads, err := payloadStrings(s.filteredEvents)
+	if err != nil {
+		return err
+	}
+	d := cmp.Diff(strings.Split(expected, ","), payloads)
+	if d != "" {
+		return fmt.Errorf("unexpected events:\n%s", d)
+	}
+	return nil
+}
+
+func oneEventShouldBeMissing(ctx context.Context) error {
+	s := getState(ctx)
+	if len(s.missing) != 1 {
+		return fmt.Errorf("expected one missing event, got %v", s.missing)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/draganm/event-buffer/client"
+)
+
+func initializeMetadataSteps(ctx *godog.ScenarioContext) {
+	ctx.Step(`^I send the event "([^"]*)" with the key "([^"]*)", the content type "([^"]*)" and the header "([^"]*)" set to "([^"]*)"$`, iSendTheEventWithTheKeyTheContentTypeAndTheHeaderSetTo)
+	ctx.Step(`^I poll for the events with metadata$`, iPollForTheEventsWithMetadata)
+	ctx.Step(`^the polled event should have the key "([^"]*)"$`, thePolledEventShouldHaveTheKey)
+	ctx.Step(`^the polled event should have the content type "([^"]*)"$`, thePolledEventShouldHaveTheContentType)
+	ctx.Step(`^the polled event should have the header "([^"]*)" set to "([^"]*)"$`, thePolledEventShouldHaveTheHeaderSetTo)
+	ctx.Step(`^the polled event should have a receive time$`, thePolledEventShouldHaveAReceiveTime)
+}
+
+func iSendTheEventWithTheKeyTheContentTypeAndTheHeaderSetTo(ctx context.Context, evt, key, contentType, header, value string) error {
+	s := getState(ctx)
+	_, err := s.client.SendBatch(ctx, client.Batch{
+		Events: []client.BatchEvent{
+			{
+				Payload:     evt,
+				Key:         key,
+				ContentType: contentType,
+				Headers:     map[string]string{header: value},
+			},
+		},
+	})
+	return err
+}
+
+func iPollForTheEventsWithMetadata(ctx context.Context) error {
+	s := getState(ctx)
+	events, err := s.client.Poll(ctx, "", 100)
+	if err != nil {
+		return fmt.Errorf("could not poll: %w", err)
+	}
+	if len(events) != 1 {
+		return fmt.Errorf("expected 1 event, got %d", len(events))
+	}
+	s.polledEvent = events[0]
+	return nil
+}
+
+func thePolledEventShouldHaveTheKey(ctx context.Context, key string) error {
+	s := getState(ctx)
+	if s.polledEvent.Key != key {
+		return fmt.Errorf("expected key %q, got %q", key, s.polledEvent.Key)
+	}
+	return nil
+}
+
+func thePolledEventShouldHaveTheContentType(ctx context.Context, contentType string) error {
+	s := getState(ctx)
+	if s.polledEvent.ContentType != contentType {
+		return fmt.Errorf("expected content type %q, got %q", contentType, s.polledEvent.ContentType)
+	}
+	return nil
+}
+
+func thePolledEventShouldHaveTheHeaderSetTo(ctx context.Context, header, value string) error {
+	s := getState(ctx)
+	if s.polledEvent.Headers[header] != value {
+		return fmt.Errorf("expected header %s to be %q, got %q", header, value, s.polledEvent.Headers[header])
+	}
+	return nil
+}
+
+func thePolledEventShouldHaveAReceiveTime(ctx context.Context) error {
+	s := getState(ctx)
+	if time.Since(s.polledEvent.ReceivedAt) > time.Minute {
+		return fmt.Errorf("unexpected receive time %s", s.polledEvent.ReceivedAt)
+	}
+	return nil
+}
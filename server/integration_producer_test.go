@@ -0,0 +1,95 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/draganm/event-buffer/client"
+)
+
+func initializeProducerSteps(ctx *godog.ScenarioContext) {
+	ctx.Step(`^a producer with a batch size of (\d+) and a linger time of (\S+)$`, aProducerWithABatchSizeOfAndALingerTimeOf)
+	ctx.Step(`^a producer for the topic "([^"]*)"$`, aProducerForTheTopic)
+	ctx.Step(`^I produce the events "([^"]*)"$`, iProduceTheEvents)
+	ctx.Step(`^I close the producer$`, iCloseTheProducer)
+	ctx.Step(`^all deliveries should succeed$`, allDeliveriesShouldSucceed)
+	ctx.Step(`^all deliveries should fail$`, allDeliveriesShouldFail)
+}
+
+func aProducerWithABatchSizeOfAndALingerTimeOf(ctx context.Context, batchSize int, linger string) error {
+	s := getState(ctx)
+	d, err := time.ParseDuration(linger)
+	if err != nil {
+		return err
+	}
+	s.producer = s.client.NewProducer(client.WithProducerBatchSize(batchSize), client.WithLinger(d))
+	return nil
+}
+
+func aProducerForTheTopic(ctx context.Context, topic string) error {
+	s := getState(ctx)
+	s.producer = s.client.Topic(topic).NewProducer()
+	return nil
+}
+
+func iProduceTheEvents(ctx context.Context, events string) error {
+	s := getState(ctx)
+	for _, e := range strings.Split(events, ",") {
+		d, err := s.producer.Send(ctx, e)
+		if err != nil {
+			return err
+		}
+		s.deliveries = append(s.deliveries, d)
+	}
+	return nil
+}
+
+func iCloseTheProducer(ctx context.Context) error {
+	return getState(ctx).producer.Close(ctx)
+}
+
+func waitForDeliveries(ctx context.Context) ([]error, error) {
+	s := getState(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	errs := []error{}
+	for _, d := range s.deliveries {
+		select {
+		case <-d.Done():
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timed out waiting for deliveries")
+		}
+		_, err := d.Result()
+		errs = append(errs, err)
+	}
+	return errs, nil
+}
+
+func allDeliveriesShouldSucceed(ctx context.Context) error {
+	errs, err := waitForDeliveries(ctx)
+	if err != nil {
+		return err
+	}
+	for _, err := range errs {
+		if err != nil {
+			return fmt.Errorf("delivery failed: %w", err)
+		}
+	}
+	return nil
+}
+
+func allDeliveriesShouldFail(ctx context.Context) error {
+	errs, err := waitForDeliveries(ctx)
+	if err != nil {
+		return err
+	}
+	for _, err := range errs {
+		if err == nil {
+			return fmt.Errorf("expected delivery to fail")
+		}
+	}
+	return nil
+}
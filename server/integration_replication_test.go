@@ -0,0 +1,224 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/draganm/event-buffer/client"
+	"github.com/draganm/event-buffer/eventbufferpb"
+	"github.com/draganm/event-buffer/server"
+	"github.com/draganm/event-buffer/server/testrig"
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func initializeReplicationSteps(ctx *godog.ScenarioContext) {
+	ctx.Step(`^a follower replicates the server$`, aFollowerReplicatesTheServer)
+	ctx.Step(`^a follower replicates the server and forwards writes$`, aFollowerReplicatesTheServerAndForwardsWrites)
+	ctx.Step(`^the follower should eventually have the same events as the server in the topic "([^"]*)"$`, theFollowerShouldEventuallyHaveTheSameEventsAsTheServerInTheTopic)
+	ctx.Step(`^the follower should eventually have the committed offset of "([^"]*)"$`, theFollowerShouldEventuallyHaveTheCommittedOffsetOf)
+	ctx.Step(`^I try to send the events "([^"]*)" to the follower$`, iTryToSendTheEventsToTheFollower)
+	ctx.Step(`^I send the events "([^"]*)" to the follower$`, iSendTheEventsToTheFollower)
+	ctx.Step(`^the follower should eventually have the same audit records as the server$`, theFollowerShouldEventuallyHaveTheSameAuditRecordsAsTheServer)
+	ctx.Step(`^I have a gRPC connection to the follower$`, iHaveAGRPCConnectionToTheFollower)
+	ctx.Step(`^the gRPC request should have been rejected as a failed precondition$`, theGRPCRequestShouldHaveBeenRejectedAsAFailedPrecondition)
+	ctx.Step(`^the follower continues replicating with the token "([^"]*)"$`, theFollowerContinuesReplicatingWithTheToken)
+	ctx.Step(`^the follower should eventually not have the topic "([^"]*)"$`, theFollowerShouldEventuallyNotHaveTheTopic)
+	ctx.Step(`^the follower should still have the topic "([^"]*)" after (\d+) milliseconds$`, theFollowerShouldStillHaveTheTopicAfterMilliseconds)
+}
+
+func startFollower(ctx context.Context, forwardWrites bool) error {
+	s := getState(ctx)
+
+	leaderURL, err := url.Parse(s.serverBaseURL)
+	if err != nil {
+		return err
+	}
+
+	rig, err := testrig.StartServer(ctx, logr.FromContextOrDiscard(ctx), server.WithLeader(leaderURL, forwardWrites), server.WithFollowRefreshInterval(100*time.Millisecond))
+	if err != nil {
+		return fmt.Errorf("could not start follower: %w", err)
+	}
+
+	followCtx, stopFollowing := context.WithCancel(ctx)
+	go rig.Server.Follow(followCtx, s.client)
+
+	s.followerServer = rig.Server
+	s.stopFollowing = stopFollowing
+	s.followerGRPCAddr = rig.GRPCAddr
+	s.follower, err = client.New(rig.URL)
+	return err
+}
+
+func aFollowerReplicatesTheServer(ctx context.Context) error {
+	return startFollower(ctx, false)
+}
+
+func aFollowerReplicatesTheServerAndForwardsWrites(ctx context.Context) error {
+	return startFollower(ctx, true)
+}
+
+func theFollowerContinuesReplicatingWithTheToken(ctx context.Context, token string) error {
+	s := getState(ctx)
+
+	leader, err := client.New(s.serverBaseURL, client.WithToken(token))
+	if err != nil {
+		return err
+	}
+
+	s.stopFollowing()
+	followCtx, stopFollowing := context.WithCancel(ctx)
+	go s.followerServer.Follow(followCtx, leader)
+	s.stopFollowing = stopFollowing
+
+	return nil
+}
+
+func theFollowerShouldEventuallyNotHaveTheTopic(ctx context.Context, topic string) error {
+	s := getState(ctx)
+	return eventually(ctx, func(ctx context.Context) error {
+		_, err := s.follower.GetTopic(ctx, topic)
+		se := &client.StatusError{}
+		if !errors.As(err, &se) || se.StatusCode != http.StatusNotFound {
+			return fmt.Errorf("expected the topic %s to be not found, got %v", topic, err)
+		}
+		return nil
+	})
+}
+
+func theFollowerShouldStillHaveTheTopicAfterMilliseconds(ctx context.Context, topic string, ms int) error {
+	s := getState(ctx)
+	time.Sleep(time.Duration(ms) * time.Millisecond)
+	_, err := s.follower.GetTopic(ctx, topic)
+	if err != nil {
+		return fmt.Errorf("could not get the topic %s of the follower: %w", topic, err)
+	}
+	return nil
+}
+
+// eventually retries the check until it succeeds or the timeout expires.
+func eventually(ctx context.Context, check func(ctx context.Context) error) error {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		err := check(checkCtx)
+		cancel()
+		if err == nil || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func theFollowerShouldEventuallyHaveTheSameEventsAsTheServerInTheTopic(ctx context.Context, topic string) error {
+	s := getState(ctx)
+
+	expected, err := s.client.Topic(topic).Poll(ctx, "", 100)
+	if err != nil {
+		return fmt.Errorf("could not poll the server: %w", err)
+	}
+
+	return eventually(ctx, func(ctx context.Context) error {
+		replicated, err := s.follower.Topic(topic).Poll(ctx, "", 100)
+		if err != nil {
+			return fmt.Errorf("could not poll the follower: %w", err)
+		}
+		d := cmp.Diff(expected, replicated)
+		if d != "" {
+			return fmt.Errorf("unexpected replicated events:\n%s", d)
+		}
+		return nil
+	})
+}
+
+func theFollowerShouldEventuallyHaveTheCommittedOffsetOf(ctx context.Context, group string) error {
+	s := getState(ctx)
+
+	expected, err := s.client.CommittedOffset(ctx, group)
+	if err != nil {
+		return err
+	}
+
+	return eventually(ctx, func(ctx context.Context) error {
+		offset, err := s.follower.CommittedOffset(ctx, group)
+		if err != nil {
+			return err
+		}
+		if offset != expected {
+			return fmt.Errorf("expected offset %q, got %q", expected, offset)
+		}
+		return nil
+	})
+}
+
+func sendToFollower(ctx context.Context, events string) error {
+	s := getState(ctx)
+	evts := []any{}
+	for _, e := range strings.Split(events, ",") {
+		evts = append(evts, e)
+	}
+	_, err := s.follower.SendEvents(ctx, evts)
+	return err
+}
+
+func iTryToSendTheEventsToTheFollower(ctx context.Context, events string) error {
+	getState(ctx).sendErr = sendToFollower(ctx, events)
+	return nil
+}
+
+func iSendTheEventsToTheFollower(ctx context.Context, events string) error {
+	return sendToFollower(ctx, events)
+}
+
+func theFollowerShouldEventuallyHaveTheSameAuditRecordsAsTheServer(ctx context.Context) error {
+	s := getState(ctx)
+
+	expected, err := s.client.AuditRecords(ctx)
+	if err != nil {
+		return fmt.Errorf("could not read the audit records of the server: %w", err)
+	}
+
+	return eventually(ctx, func(ctx context.Context) error {
+		replicated, err := s.follower.AuditRecords(ctx)
+		if err != nil {
+			return fmt.Errorf("could not read the audit records of the follower: %w", err)
+		}
+		d := cmp.Diff(expected, replicated)
+		if d != "" {
+			return fmt.Errorf("unexpected replicated audit records:\n%s", d)
+		}
+		return nil
+	})
+}
+
+func iHaveAGRPCConnectionToTheFollower(ctx context.Context) error {
+	s := getState(ctx)
+	conn, err := grpc.DialContext(ctx, s.followerGRPCAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return fmt.Errorf("could not dial grpc: %w", err)
+	}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+	s.grpcClient = eventbufferpb.NewEventBufferClient(conn)
+	return nil
+}
+
+func theGRPCRequestShouldHaveBeenRejectedAsAFailedPrecondition(ctx context.Context) error {
+	s := getState(ctx)
+	if status.Code(s.sendErr) != codes.FailedPrecondition {
+		return fmt.Errorf("expected failed precondition error, got %v", s.sendErr)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/draganm/event-buffer/server"
+)
+
+func initializeRetentionSteps(ctx *godog.ScenarioContext) {
+	ctx.Step(`^a server retaining at most (\d+) events per topic$`, aServerRetainingAtMostEventsPerTopic)
+	ctx.Step(`^a server retaining at most (\d+) events per topic on write$`, aServerRetainingAtMostEventsPerTopicOnWrite)
+	ctx.Step(`^a server retaining at most (\d+) bytes per topic$`, aServerRetainingAtMostBytesPerTopic)
+	ctx.Step(`^a server keeping uncommitted events for at most (\S+)$`, aServerKeepingUncommittedEventsForAtMost)
+	ctx.Step(`^I send the events "([^"]*)"$`, iSendTheEvents)
+	ctx.Step(`^the buffer is pruned$`, theBufferIsPruned)
+	ctx.Step(`^the buffer is pruned of all events older than now$`, theBufferIsPrunedOfAllEventsOlderThanNow)
+	ctx.Step(`^the default topic should be empty$`, theDefaultTopicShouldBeEmpty)
+}
+
+func aServerRetainingAtMostEventsPerTopic(ctx context.Context, maxEvents int64) error {
+	return startServer(ctx, server.WithMaxEvents(maxEvents))
+}
+
+func aServerRetainingAtMostEventsPerTopicOnWrite(ctx context.Context, maxEvents int64) error {
+	return startServer(ctx, server.WithMaxEvents(maxEvents), server.WithRetentionOnWrite(true))
+}
+
+func aServerRetainingAtMostBytesPerTopic(ctx context.Context, maxBytes int64) error {
+	return startServer(ctx, server.WithMaxBytes(maxBytes))
+}
+
+func aServerKeepingUncommittedEventsForAtMost(ctx context.Context, maxAge string) error {
+	d, err := time.ParseDuration(maxAge)
+	if err != nil {
+		return err
+	}
+	return startServer(ctx, server.WithConsumerAwareRetention(d))
+}
+
+func iSendTheEvents(ctx context.Context, events string) error {
+	s := getState(ctx)
+	evts := []any{}
+	for _, e := range strings.Split(events, ",") {
+		evts = append(evts, e)
+	}
+	published, err := s.client.SendEvents(ctx, evts)
+	if err != nil {
+		return err
+	}
+	s.published = published
+	return nil
+}
+
+func theBufferIsPruned(ctx context.Context) error {
+	s := getState(ctx)
+	return s.server.Prune(time.Time{})
+}
+
+func theBufferIsPrunedOfAllEventsOlderThanNow(ctx context.Context) error {
+	s := getState(ctx)
+	return s.server.Prune(time.Now().Add(time.Second))
+}
+
+func theDefaultTopicShouldBeEmpty(ctx context.Context) error {
+	s := getState(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	evts := []string{}
+	_, err := s.client.PollForEvents(ctx, "", 100, &evts)
+	if err == nil {
+		return fmt.Errorf("expected no events, got %v", evts)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/draganm/event-buffer/client"
+)
+
+func initializeRetriesSteps(ctx *godog.ScenarioContext) {
+	ctx.Step(`^a client connected through a faulty proxy with at most (\d+) attempts$`, aClientConnectedThroughAFaultyProxyWithAtMostAttempts)
+	ctx.Step(`^a client retrying publishes through a faulty proxy with at most (\d+) attempts$`, aClientRetryingPublishesThroughAFaultyProxyWithAtMostAttempts)
+	ctx.Step(`^a client connected through a faulty proxy with a circuit breaker opening after (\d+) failures$`, aClientConnectedThroughAFaultyProxyWithACircuitBreakerOpeningAfterFailures)
+	ctx.Step(`^the proxy fails the next (\d+) requests$`, theProxyFailsTheNextRequests)
+	ctx.Step(`^the proxy loses the response of the next request$`, theProxyLosesTheResponseOfTheNextRequest)
+	ctx.Step(`^I try to send the events "([^"]*)"$`, iTryToSendTheEvents)
+	ctx.Step(`^sending should have failed because the circuit is open$`, sendingShouldHaveFailedBecauseTheCircuitIsOpen)
+	ctx.Step(`^a client connected through a faulty proxy with a circuit breaker opening after (\d+) failures for (\d+) milliseconds$`, aClientConnectedThroughAFaultyProxyWithACircuitBreakerOpeningAfterFailuresForMilliseconds)
+	ctx.Step(`^the circuit breaker has been open for (\d+) milliseconds$`, theCircuitBreakerHasBeenOpenForMilliseconds)
+	ctx.Step(`^the proxy stalls the next request$`, theProxyStallsTheNextRequest)
+	ctx.Step(`^I try to send the events "([^"]*)" and cancel after (\d+) milliseconds$`, iTryToSendTheEventsAndCancelAfterMilliseconds)
+}
+
+// faultyProxy forwards requests to the server, failing some of them with 503.
+type faultyProxy struct {
+	proxy *httputil.ReverseProxy
+
+	mu        sync.Mutex
+	failNext  int
+	loseNext  int
+	stallNext int
+}
+
+func (p *faultyProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	fail := p.failNext > 0
+	if fail {
+		p.failNext--
+	}
+	lose := !fail && p.loseNext > 0
+	if lose {
+		p.loseNext--
+	}
+	stall := !fail && !lose && p.stallNext > 0
+	if stall {
+		p.stallNext--
+	}
+	p.mu.Unlock()
+
+	if stall {
+		// the request never reaches the server
+		<-r.Context().Done()
+		return
+	}
+
+	if fail {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	if lose {
+		// the server processes the request, but the client doesn't get the response
+		p.proxy.ServeHTTP(httptest.NewRecorder(), r)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	p.proxy.ServeHTTP(w, r)
+}
+
+func connectThroughFaultyProxy(ctx context.Context, opts ...client.Option) error {
+	s := getState(ctx)
+
+	u, err := url.Parse(s.serverBaseURL)
+	if err != nil {
+		return err
+	}
+
+	s.proxy = &faultyProxy{proxy: httputil.NewSingleHostReverseProxy(u)}
+	ps := httptest.NewServer(s.proxy)
+	go func() {
+		<-ctx.Done()
+		ps.Close()
+	}()
+
+	s.client, err = client.New(ps.URL, opts...)
+	return err
+}
+
+func aClientConnectedThroughAFaultyProxyWithAtMostAttempts(ctx context.Context, attempts int) error {
+	p := client.DefaultRetryPolicy
+	p.MaxAttempts = attempts
+	p.MinDelay = time.Millisecond
+	p.MaxDelay = 10 * time.Millisecond
+	return connectThroughFaultyProxy(ctx, client.WithRetryPolicy(p))
+}
+
+func aClientRetryingPublishesThroughAFaultyProxyWithAtMostAttempts(ctx context.Context, attempts int) error {
+	p := client.DefaultRetryPolicy
+	p.MaxAttempts = attempts
+	p.RetryPublishes = true
+	p.MinDelay = time.Millisecond
+	p.MaxDelay = 10 * time.Millisecond
+	return connectThroughFaultyProxy(ctx, client.WithRetryPolicy(p))
+}
+
+func aClientConnectedThroughAFaultyProxyWithACircuitBreakerOpeningAfterFailures(ctx context.Context, failures int) error {
+	p := client.DefaultRetryPolicy
+	p.MaxAttempts = 1
+	return connectThroughFaultyProxy(ctx, client.WithRetryPolicy(p), client.WithCircuitBreaker(failures, time.Hour))
+}
+
+func aClientConnectedThroughAFaultyProxyWithACircuitBreakerOpeningAfterFailuresForMilliseconds(ctx context.Context, failures, ms int) error {
+	p := client.DefaultRetryPolicy
+	p.MaxAttempts = 1
+	return connectThroughFaultyProxy(ctx, client.WithRetryPolicy(p), client.WithCircuitBreaker(failures, time.Duration(ms)*time.Millisecond))
+}
+
+func theCircuitBreakerHasBeenOpenForMilliseconds(ctx context.Context, ms int) error {
+	time.Sleep(time.Duration(ms) * time.Millisecond)
+	return nil
+}
+
+func theProxyStallsTheNextRequest(ctx context.Context) error {
+	s := getState(ctx)
+	s.proxy.mu.Lock()
+	defer s.proxy.mu.Unlock()
+	s.proxy.stallNext = 1
+	return nil
+}
+
+func theProxyFailsTheNextRequests(ctx context.Context, n int) error {
+	s := getState(ctx)
+	s.proxy.mu.Lock()
+	defer s.proxy.mu.Unlock()
+	s.proxy.failNext = n
+	return nil
+}
+
+func theProxyLosesTheResponseOfTheNextRequest(ctx context.Context) error {
+	s := getState(ctx)
+	s.proxy.mu.Lock()
+	defer s.proxy.mu.Unlock()
+	s.proxy.loseNext = 1
+	return nil
+}
+
+func iTryToSendTheEvents(ctx context.Context, events string) error {
+	s := getState(ctx)
+	evts := []any{}
+	for _, e := range strings.Split(events, ",") {
+		evts = append(evts, e)
+	}
+	_, s.sendErr = s.client.SendEvents(ctx, evts)
+	return nil
+}
+
+func iTryToSendTheEventsAndCancelAfterMilliseconds(ctx context.Context, events string, ms int) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(ms)*time.Millisecond)
+	defer cancel()
+	return iTryToSendTheEvents(ctx, events)
+}
+
+func sendingShouldHaveFailedBecauseTheCircuitIsOpen(ctx context.Context) error {
+	s := getState(ctx)
+	if !errors.Is(s.sendErr, client.ErrCircuitOpen) {
+		return fmt.Errorf("expected the circuit to be open, got %v", s.sendErr)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cucumber/godog"
+	"github.com/draganm/event-buffer/client"
+	"github.com/google/go-cmp/cmp"
+)
+
+func initializeSchemaSteps(ctx *godog.ScenarioContext) {
+	ctx.Step(`^the schema of the topic "([^"]*)" is$`, theSchemaOfTheTopicIs)
+	ctx.Step(`^the schema of the topic "([^"]*)" is registered with "([^"]*)" compatibility$`, theSchemaOfTheTopicIsRegisteredWithCompatibility)
+	ctx.Step(`^the schema of the event type "([^"]*)" of the topic "([^"]*)" is$`, theSchemaOfTheEventTypeOfTheTopicIs)
+	ctx.Step(`^I try to register the schema of the topic "([^"]*)" with "([^"]*)" compatibility$`, iTryToRegisterTheSchemaOfTheTopicWithCompatibility)
+	ctx.Step(`^I delete the schema of the topic "([^"]*)"$`, iDeleteTheSchemaOfTheTopic)
+	ctx.Step(`^I try to send the batch$`, iTryToSendTheBatch)
+	ctx.Step(`^the events should have been accepted$`, theEventsShouldHaveBeenAccepted)
+	ctx.Step(`^the event (\d+) should have been rejected at "([^"]*)" with "([^"]*)"$`, theEventShouldHaveBeenRejectedAtWith)
+	ctx.Step(`^the topic "([^"]*)" should have the schema versions "([^"]*)"$`, theTopicShouldHaveTheSchemaVersions)
+}
+
+func theSchemaOfTheTopicIs(ctx context.Context, topic string, schema *godog.DocString) error {
+	s := getState(ctx)
+	_, err := s.client.Topic(topic).RegisterSchema(ctx, client.SchemaRegistration{Schema: json.RawMessage(schema.Content)})
+	return err
+}
+
+func theSchemaOfTheTopicIsRegisteredWithCompatibility(ctx context.Context, topic, compatibility string, schema *godog.DocString) error {
+	s := getState(ctx)
+	_, err := s.client.Topic(topic).RegisterSchema(ctx, client.SchemaRegistration{Schema: json.RawMessage(schema.Content), Compatibility: compatibility})
+	return err
+}
+
+func theSchemaOfTheEventTypeOfTheTopicIs(ctx context.Context, eventType, topic string, schema *godog.DocString) error {
+	s := getState(ctx)
+	_, err := s.client.Topic(topic).RegisterSchema(ctx, client.SchemaRegistration{Type: eventType, Schema: json.RawMessage(schema.Content)})
+	return err
+}
+
+func iTryToRegisterTheSchemaOfTheTopicWithCompatibility(ctx context.Context, topic, compatibility string, schema *godog.DocString) error {
+	s := getState(ctx)
+	_, s.sendErr = s.client.Topic(topic).RegisterSchema(ctx, client.SchemaRegistration{Schema: json.RawMessage(schema.Content), Compatibility: compatibility})
+	return nil
+}
+
+func iDeleteTheSchemaOfTheTopic(ctx context.Context, topic string) error {
+	s := getState(ctx)
+	return s.client.Topic(topic).DeleteSchema(ctx, "")
+}
+
+func iTryToSendTheBatch(ctx context.Context, events *godog.DocString) error {
+	s := getState(ctx)
+	batch := client.Batch{}
+	err := json.Unmarshal([]byte(events.Content), &batch.Events)
+	if err != nil {
+		return err
+	}
+	_, s.sendErr = s.client.SendBatch(ctx, batch)
+	return nil
+}
+
+func theEventsShouldHaveBeenAccepted(ctx context.Context) error {
+	s := getState(ctx)
+	return s.sendErr
+}
+
+func theEventShouldHaveBeenRejectedAtWith(ctx context.Context, index int, location, message string) error {
+	s := getState(ctx)
+	for _, v := range client.SchemaViolations(s.sendErr) {
+		if v.Index != index {
+			continue
+		}
+		for _, e := range v.Errors {
+			if e.InstanceLocation == location && e.Message == message {
+				return nil
+			}
+		}
+		return fmt.Errorf("unexpected errors of event %d: %v", index, v.Errors)
+	}
+	return fmt.Errorf("event %d was not rejected: %v", index, s.sendErr)
+}
+
+func theTopicShouldHaveTheSchemaVersions(ctx context.Context, topic, expected string) error {
+	s := getState(ctx)
+	schemas, err := s.client.Topic(topic).Schemas(ctx)
+	if err != nil {
+		return err
+	}
+	versions := []string{}
+	for _, sch := range schemas {
+		versions = append(versions, strconv.Itoa(sch.Version))
+	}
+	d := cmp.Diff(strings.Split(expected, ","), versions)
+	if d != "" {
+		return fmt.Errorf("unexpected schema versions:\n%s", d)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/draganm/event-buffer/client"
+	"github.com/google/go-cmp/cmp"
+)
+
+func initializeSeekSteps(ctx *godog.ScenarioContext) {
+	ctx.Step(`^I remember the current time$`, iRememberTheCurrentTime)
+	ctx.Step(`^I remember the current time as the end$`, iRememberTheCurrentTimeAsTheEnd)
+	ctx.Step(`^I poll the events since the remembered time$`, iPollTheEventsSinceTheRememberedTime)
+	ctx.Step(`^I poll the events until the remembered time$`, iPollTheEventsUntilTheRememberedTime)
+	ctx.Step(`^the polled events should be "([^"]*)"$`, thePolledEventsShouldBe)
+	ctx.Step(`^I replay the events between the remembered times$`, iReplayTheEventsBetweenTheRememberedTimes)
+	ctx.Step(`^the replayed events should be "([^"]*)"$`, theReplayedEventsShouldBe)
+	ctx.Step(`^I try to poll the events since now with "([^"]*)"$`, iTryToPollTheEventsSinceNowWith)
+}
+
+// rememberTime returns the current time, making sure that events published before and after it have different times.
+func rememberTime() time.Time {
+	time.Sleep(time.Millisecond)
+	t := time.Now()
+	time.Sleep(time.Millisecond)
+	return t
+}
+
+func iRememberTheCurrentTime(ctx context.Context) error {
+	s := getState(ctx)
+	s.since = rememberTime()
+	return nil
+}
+
+func iRememberTheCurrentTimeAsTheEnd(ctx context.Context) error {
+	s := getState(ctx)
+	s.until = rememberTime()
+	return nil
+}
+
+// pollEvents polls the default topic with the query parameters.
+func pollEvents(ctx context.Context, q url.Values) ([]string, error) {
+	s := getState(ctx)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", s.serverBaseURL+"/events?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, &client.StatusError{StatusCode: res.StatusCode, Status: res.Status}
+	}
+
+	events := []client.Event{}
+	err = json.NewDecoder(res.Body).Decode(&events)
+	if err != nil {
+		return nil, err
+	}
+
+	payloads, err := payloadStrings(events)
+	if err != nil {
+		return nil, err
+	}
+
+	return payloads, nil
+}
+
+func iPollTheEventsSinceTheRememberedTime(ctx context.Context) error {
+	s := getState(ctx)
+	events, err := pollEvents(ctx, url.Values{"since": {s.since.Format(time.RFC3339Nano)}})
+	if err != nil {
+		return err
+	}
+	s.pollResult = events
+	return nil
+}
+
+func iPollTheEventsUntilTheRememberedTime(ctx context.Context) error {
+	s := getState(ctx)
+	events, err := pollEvents(ctx, url.Values{"until": {s.since.Format(time.RFC3339Nano)}})
+	if err != nil {
+		return err
+	}
+	s.pollResult = events
+	return nil
+}
+
+func splitEvents(events string) []string {
+	if events == "" {
+		return []string{}
+	}
+	return strings.Split(events, ",")
+}
+
+func thePolledEventsShouldBe(ctx context.Context, expected string) error {
+	s := getState(ctx)
+	d := cmp.Diff(splitEvents(expected), s.pollResult)
+	if d != "" {
+		return fmt.Errorf("unexpected poll result:\n%s", d)
+	}
+	return nil
+}
+
+func iReplayTheEventsBetweenTheRememberedTimes(ctx context.Context) error {
+	s := getState(ctx)
+	s.replayed = []client.Event{}
+	return s.client.SubscribeSince(ctx, s.since, func(ctx context.Context, events []client.Event) error {
+		s.replayed = append(s.replayed, events...)
+		return nil
+	}, client.WithUntil(s.until), client.WithBatchSize(1))
+}
+
+func theReplayedEventsShouldBe(ctx context.Context, expected string) error {
+	s := getState(ctx)
+	payloads, err := payloadStrings(s.replayed)
+	if err != nil {
+		return err
+	}
+	d := cmp.Diff(splitEvents(expected), payloads)
+	if d != "" {
+		return fmt.Errorf("unexpected replayed events:\n%s", d)
+	}
+	return nil
+}
+
+// iTryToPollTheEventsSinceNowWith polls with the additional query parameters, "first" is replaced by the ID of the first event.
+func iTryToPollTheEventsSinceNowWith(ctx context.Context, parameters string) error {
+	s := getState(ctx)
+
+	q, err := url.ParseQuery(parameters)
+	if err != nil {
+		return err
+	}
+
+	for name, values := range q {
+		for i, v := range values {
+			if v == "first" {
+				q[name][i] = s.published[0].ID
+			}
+		}
+	}
+
+	q.Set("since", time.Now().Format(time.RFC3339Nano))
+	_, s.sendErr = pollEvents(ctx, q)
+	return nil
+}
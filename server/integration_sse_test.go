@@ -0,0 +1,91 @@
+package server_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/google/go-cmp/cmp"
+)
+
+func initializeSSESteps(ctx *godog.ScenarioContext) {
+	ctx.Step(`^I open an event stream$`, iOpenAnEventStream)
+	ctx.Step(`^I open an event stream with the polled event as last event ID$`, iOpenAnEventStreamWithThePolledEventAsLastEventID)
+	ctx.Step(`^the event stream should deliver "([^"]*)"$`, theEventStreamShouldDeliver)
+}
+
+func openEventStream(ctx context.Context, lastEventID string) error {
+	s := getState(ctx)
+	req, err := http.NewRequestWithContext(ctx, "GET", s.serverBaseURL+"/events", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("accept", "text/event-stream")
+	if lastEventID != "" {
+		req.Header.Set("last-event-id", lastEventID)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return fmt.Errorf("unexpected status %s", res.Status)
+	}
+
+	s.streamedEvents = make(chan string, 100)
+
+	go func() {
+		defer res.Body.Close()
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if strings.HasPrefix(line, "data: ") {
+				var evt string
+				err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &evt)
+				if err != nil {
+					continue
+				}
+				s.streamedEvents <- evt
+			}
+		}
+	}()
+
+	return nil
+}
+
+func iOpenAnEventStream(ctx context.Context) error {
+	return openEventStream(ctx, "")
+}
+
+func iOpenAnEventStreamWithThePolledEventAsLastEventID(ctx context.Context) error {
+	s := getState(ctx)
+	return openEventStream(ctx, s.lastId)
+}
+
+func theEventStreamShouldDeliver(ctx context.Context, expected string) error {
+	s := getState(ctx)
+	expectedEvents := strings.Split(expected, ",")
+	received := []string{}
+	timeout := time.After(5 * time.Second)
+	for len(received) < len(expectedEvents) {
+		select {
+		case evt := <-s.streamedEvents:
+			received = append(received, evt)
+		case <-timeout:
+			return fmt.Errorf("timed out waiting for events, received %v", received)
+		}
+	}
+	d := cmp.Diff(received, expectedEvents)
+	if d != "" {
+		return fmt.Errorf("unexpected streamed events:\n%s", d)
+	}
+	return nil
+}
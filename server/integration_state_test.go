@@ -1,7 +1,14 @@
 package server_test
 
 import (
+	"context"
+	"time"
+
+	"github.com/draganm/bolted"
 	"github.com/draganm/event-buffer/client"
+	"github.com/draganm/event-buffer/eventbufferpb"
+	"github.com/draganm/event-buffer/server"
+	"github.com/gorilla/websocket"
 )
 
 type StateKeyType string
@@ -14,10 +21,44 @@ type eventsOrError struct {
 }
 
 type State struct {
-	// serverBaseURL    string
+	serverBaseURL    string
 	client           *client.Client
+	server           *server.Server
 	pollResult       []string
 	secondPollResult []string
 	longPollResult   chan eventsOrError
 	lastId           string
+	sendErr          error
+	topics           []string
+	streamedEvents   chan string
+	wsConn           *websocket.Conn
+	grpcAddr         string
+	grpcClient       eventbufferpb.EventBufferClient
+	grpcEvents       chan string
+	sentIDs          [][]client.PublishedEvent
+	published        []client.PublishedEvent
+	responseBody     []byte
+	polledEvent      client.Event
+	filteredEvents   []client.Event
+	cursor           string
+	subscribed       chan string
+	subscribeErr     chan error
+	producer         *client.Producer
+	deliveries       []*client.Delivery
+	proxy            *faultyProxy
+	pki              *testPKI
+	follower         *client.Client
+	followerGRPCAddr string
+	followerServer   *server.Server
+	stopFollowing    context.CancelFunc
+	restoreErr       error
+	backedUpDB       bolted.Database
+	db               bolted.Database
+	exported         []server.ExportedEvent
+	importErr        error
+	stats            []client.TopicStats
+	since            time.Time
+	until            time.Time
+	replayed         []client.Event
+	missing          []string
 }
@@ -0,0 +1,79 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cucumber/godog"
+	"github.com/draganm/event-buffer/client"
+)
+
+func initializeStatsSteps(ctx *godog.ScenarioContext) {
+	ctx.Step(`^I get the stats$`, iGetTheStats)
+	ctx.Step(`^the stats of the topic "([^"]*)" should show (\d+) events$`, theStatsOfTheTopicShouldShowEvents)
+	ctx.Step(`^the newest event of the topic "([^"]*)" should be the last sent event$`, theNewestEventOfTheTopicShouldBeTheLastSentEvent)
+	ctx.Step(`^the lag of the consumer group "([^"]*)" of the topic "([^"]*)" should be (\d+)$`, theLagOfTheConsumerGroupOfTheTopicShouldBe)
+}
+
+func iGetTheStats(ctx context.Context) error {
+	s := getState(ctx)
+	stats, err := s.client.Stats(ctx)
+	if err != nil {
+		return err
+	}
+	s.stats = stats
+	return nil
+}
+
+func topicStats(ctx context.Context, topic string) (client.TopicStats, error) {
+	s := getState(ctx)
+	for _, ts := range s.stats {
+		if ts.Topic == topic {
+			return ts, nil
+		}
+	}
+	return client.TopicStats{}, fmt.Errorf("no stats of topic %s", topic)
+}
+
+func theStatsOfTheTopicShouldShowEvents(ctx context.Context, topic string, events int) error {
+	ts, err := topicStats(ctx, topic)
+	if err != nil {
+		return err
+	}
+	if ts.Events != events {
+		return fmt.Errorf("expected %d events, got %d", events, ts.Events)
+	}
+	return nil
+}
+
+func theNewestEventOfTheTopicShouldBeTheLastSentEvent(ctx context.Context, topic string) error {
+	s := getState(ctx)
+	ts, err := topicStats(ctx, topic)
+	if err != nil {
+		return err
+	}
+	if ts.Newest == nil {
+		return fmt.Errorf("topic %s has no newest event", topic)
+	}
+	last := s.published[len(s.published)-1]
+	if ts.Newest.ID != last.ID {
+		return fmt.Errorf("expected newest event %s, got %s", last.ID, ts.Newest.ID)
+	}
+	return nil
+}
+
+func theLagOfTheConsumerGroupOfTheTopicShouldBe(ctx context.Context, group, topic string, lag int) error {
+	ts, err := topicStats(ctx, topic)
+	if err != nil {
+		return err
+	}
+	for _, g := range ts.Groups {
+		if g.Group == group {
+			if g.Lag != lag {
+				return fmt.Errorf("expected lag %d, got %d", lag, g.Lag)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("no stats of consumer group %s", group)
+}
@@ -0,0 +1,95 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/draganm/event-buffer/client"
+	"github.com/google/go-cmp/cmp"
+)
+
+func initializeSubscribeSteps(ctx *godog.ScenarioContext) {
+	ctx.Step(`^I subscribe to the events$`, iSubscribeToTheEvents)
+	ctx.Step(`^I subscribe to the events after the first sent event$`, iSubscribeToTheEventsAfterTheFirstSentEvent)
+	ctx.Step(`^I subscribe to the events matching "([^"]*)"$`, iSubscribeToTheEventsMatching)
+	ctx.Step(`^I subscribe to the events of the topic "([^"]*)"$`, iSubscribeToTheEventsOfTheTopic)
+	ctx.Step(`^the subscription should deliver the events with the IDs "([^"]*)"$`, theSubscriptionShouldDeliverTheEventsWithTheIDs)
+	ctx.Step(`^the subscription should fail$`, theSubscriptionShouldFail)
+}
+
+type testPayload struct {
+	ID string `json:"id"`
+}
+
+func subscribe(ctx context.Context, cl *client.Client, from string, opts ...client.SubscribeOption) {
+	s := getState(ctx)
+	s.subscribed = make(chan string, 100)
+	s.subscribeErr = make(chan error, 1)
+	go func() {
+		s.subscribeErr <- client.SubscribeTyped(ctx, cl, from, func(ctx context.Context, events []client.TypedEvent[testPayload]) error {
+			for _, e := range events {
+				s.subscribed <- e.Data.ID
+			}
+			return nil
+		}, opts...)
+	}()
+}
+
+func iSubscribeToTheEvents(ctx context.Context) error {
+	subscribe(ctx, getState(ctx).client, "")
+	return nil
+}
+
+func iSubscribeToTheEventsAfterTheFirstSentEvent(ctx context.Context) error {
+	s := getState(ctx)
+	subscribe(ctx, s.client, s.published[0].ID)
+	return nil
+}
+
+func iSubscribeToTheEventsMatching(ctx context.Context, filters string) error {
+	subscribe(ctx, getState(ctx).client, "", client.WithFilters(strings.Split(filters, ";")...))
+	return nil
+}
+
+func iSubscribeToTheEventsOfTheTopic(ctx context.Context, topic string) error {
+	subscribe(ctx, getState(ctx).client.Topic(topic), "")
+	return nil
+}
+
+func theSubscriptionShouldDeliverTheEventsWithTheIDs(ctx context.Context, expected string) error {
+	s := getState(ctx)
+	expectedIDs := strings.Split(expected, ",")
+	ids := []string{}
+	timeout := time.After(5 * time.Second)
+	for len(ids) < len(expectedIDs) {
+		select {
+		case id := <-s.subscribed:
+			ids = append(ids, id)
+		case err := <-s.subscribeErr:
+			return fmt.Errorf("subscription ended: %w", err)
+		case <-timeout:
+			return fmt.Errorf("timed out waiting for events, received %v", ids)
+		}
+	}
+	d := cmp.Diff(expectedIDs, ids)
+	if d != "" {
+		return fmt.Errorf("unexpected events:\n%s", d)
+	}
+	return nil
+}
+
+func theSubscriptionShouldFail(ctx context.Context) error {
+	s := getState(ctx)
+	select {
+	case err := <-s.subscribeErr:
+		if err == nil {
+			return fmt.Errorf("expected subscription to fail")
+		}
+		return nil
+	case <-time.After(5 * time.Second):
+		return fmt.Errorf("timed out waiting for the subscription to fail")
+	}
+}
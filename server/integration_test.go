@@ -3,12 +3,17 @@ package server_test
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/cucumber/godog"
 	"github.com/draganm/event-buffer/client"
+	"github.com/draganm/event-buffer/server"
 	"github.com/draganm/event-buffer/server/testrig"
 	"github.com/go-logr/logr"
 	"github.com/go-logr/zapr"
@@ -70,25 +75,20 @@ func InitializeScenario(ctx *godog.ScenarioContext) {
 
 	ctx.Before(func(ctx context.Context, sc *godog.Scenario) (context.Context, error) {
 
-		serverURL, err := testrig.StartServer(ctx, logr.FromContextOrDiscard(ctx))
-		if err != nil {
-			return ctx, fmt.Errorf("could not start server: %w", err)
-		}
+		ctx = context.WithValue(ctx, stateKey, state)
 
-		cl, err := client.New(serverURL)
+		err := startServer(ctx)
 		if err != nil {
-			return ctx, fmt.Errorf("could not create client: %w", err)
+			return ctx, err
 		}
 
-		state.client = cl
-
-		ctx = context.WithValue(ctx, stateKey, state)
-
 		return ctx, nil
 	})
 
 	ctx.Step(`^I send a single event$`, iSendASingleEvent)
 	ctx.Step(`^I should get a confirmation$`, iShouldGetAConfirmation)
+	ctx.Step(`^I send a single event without accepting a response body$`, iSendASingleEventWithoutAcceptingAResponseBody)
+	ctx.Step(`^the response body should be empty$`, theResponseBodyShouldBeEmpty)
 	ctx.Step(`^I poll for the events$`, iPollForTheEvents)
 	ctx.Step(`^I should receive the buffered event$`, iShouldReceiveTheBufferedEvent)
 	ctx.Step(`^one event in the buffer$`, oneEventInTheBuffer)
@@ -101,29 +101,109 @@ func InitializeScenario(ctx *godog.ScenarioContext) {
 	ctx.Step(`^I should get one event for each poll$`, iShouldGetOneEventForEachPoll)
 	ctx.Step(`^two events in the buffer$`, twoEventsInTheBuffer)
 
+	initializeTopicSteps(ctx)
+	initializeGroupSteps(ctx)
+	initializeSSESteps(ctx)
+	initializeWebSocketSteps(ctx)
+	initializeGRPCSteps(ctx)
+	initializeIdempotencySteps(ctx)
+	initializeMetadataSteps(ctx)
+	initializeRetentionSteps(ctx)
+	initializeFilterSteps(ctx)
+	initializeSubscribeSteps(ctx)
+	initializeProducerSteps(ctx)
+	initializeRetriesSteps(ctx)
+	initializeAuthSteps(ctx)
+	initializeTLSSteps(ctx)
+	initializeReplicationSteps(ctx)
+	initializeBackupSteps(ctx)
+	initializeExportSteps(ctx)
+	initializeStatsSteps(ctx)
+	initializeSeekSteps(ctx)
+	initializeLookupSteps(ctx)
+	initializeEraseSteps(ctx)
+	initializeCompactionSteps(ctx)
+	initializeSchemaSteps(ctx)
+
 }
 
 func getState(ctx context.Context) *State {
 	return ctx.Value(stateKey).(*State)
 }
 
+// startServer starts a server with the given options and points the client to it.
+func startServer(ctx context.Context, opts ...server.Option) error {
+	s := getState(ctx)
+
+	rig, err := testrig.StartServer(ctx, logr.FromContextOrDiscard(ctx), opts...)
+	if err != nil {
+		return fmt.Errorf("could not start server: %w", err)
+	}
+
+	cl, err := client.New(rig.URL)
+	if err != nil {
+		return fmt.Errorf("could not create client: %w", err)
+	}
+
+	s.client = cl
+	s.serverBaseURL = rig.URL
+	s.grpcAddr = rig.GRPCAddr
+	s.server = rig.Server
+	s.db = rig.DB
+
+	return nil
+}
+
 func iSendASingleEvent(ctx context.Context) error {
 	s := getState(ctx)
-	err := s.client.SendEvents(ctx, []any{"evt1"})
+	published, err := s.client.SendEvents(ctx, []any{"evt1"})
 	if err != nil {
 		return err
 	}
+	s.published = published
 	return nil
 }
 
-func iShouldGetAConfirmation() error {
-	// actually nothing comes back
+func iShouldGetAConfirmation(ctx context.Context) error {
+	s := getState(ctx)
+	if len(s.published) != 1 {
+		return fmt.Errorf("expected 1 published event, got %d", len(s.published))
+	}
+	if time.Since(s.published[0].Time) > time.Minute {
+		return fmt.Errorf("unexpected time of the published event: %s", s.published[0].Time)
+	}
+	return nil
+}
+
+func iSendASingleEventWithoutAcceptingAResponseBody(ctx context.Context) error {
+	s := getState(ctx)
+	req, err := http.NewRequestWithContext(ctx, "POST", s.serverBaseURL+"/events", strings.NewReader(`["evt1"]`))
+	if err != nil {
+		return err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", res.Status)
+	}
+	s.responseBody, err = io.ReadAll(res.Body)
+	return err
+}
+
+func theResponseBodyShouldBeEmpty(ctx context.Context) error {
+	s := getState(ctx)
+	if len(s.responseBody) != 0 {
+		return fmt.Errorf("expected empty response body, got %q", string(s.responseBody))
+	}
 	return nil
 }
 
 func oneEventInTheBuffer(ctx context.Context) error {
 	s := getState(ctx)
-	err := s.client.SendEvents(ctx, []any{"evt1"})
+	_, err := s.client.SendEvents(ctx, []any{"evt1"})
 	if err != nil {
 		return err
 	}
@@ -173,7 +253,7 @@ func iStartPollingForTheEvents(ctx context.Context) error {
 
 func thereIsANewEventSentToTheBuffer(ctx context.Context) error {
 	s := getState(ctx)
-	err := s.client.SendEvents(ctx, []any{"evt1"})
+	_, err := s.client.SendEvents(ctx, []any{"evt1"})
 	if err != nil {
 		return err
 	}
@@ -201,7 +281,7 @@ func iShouldReceiveTheNewEvent(ctx context.Context) error {
 
 func twoEventsInTheBuffer(ctx context.Context) error {
 	s := getState(ctx)
-	err := s.client.SendEvents(ctx, []any{"evt1", "evt2"})
+	_, err := s.client.SendEvents(ctx, []any{"evt1", "evt2"})
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,340 @@
+package server_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/draganm/event-buffer/client"
+	"github.com/draganm/event-buffer/eventbufferpb"
+	"github.com/draganm/event-buffer/server"
+	"github.com/draganm/event-buffer/server/testrig"
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+func initializeTLSSteps(ctx *godog.ScenarioContext) {
+	ctx.Step(`^a server with TLS$`, aServerWithTLS)
+	ctx.Step(`^a server with mutual TLS$`, aServerWithMutualTLS)
+	ctx.Step(`^a client trusting the test CA$`, aClientTrustingTheTestCA)
+	ctx.Step(`^a client with a client certificate$`, aClientWithAClientCertificate)
+	ctx.Step(`^the server certificate is replaced$`, theServerCertificateIsReplaced)
+	ctx.Step(`^the server should present the new certificate$`, theServerShouldPresentTheNewCertificate)
+	ctx.Step(`^a follower forwarding writes with a client certificate$`, aFollowerForwardingWritesWithAClientCertificate)
+	ctx.Step(`^I have a gRPC connection with the client certificate$`, iHaveAGRPCConnectionWithTheClientCertificate)
+	ctx.Step(`^the server should negotiate HTTP/2 with the client certificate$`, theServerShouldNegotiateHTTP2WithTheClientCertificate)
+}
+
+// testPKI is a CA issuing server and client certificates stored in a temporary directory.
+type testPKI struct {
+	dir    string
+	ca     *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	serial int64
+}
+
+func (p *testPKI) path(name string) string {
+	return filepath.Join(p.dir, name)
+}
+
+func newTestPKI(ctx context.Context) (*testPKI, error) {
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		<-ctx.Done()
+		os.RemoveAll(dir)
+	}()
+
+	p := &testPKI{dir: dir}
+
+	p.caKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &p.caKey.PublicKey, p.caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	p.ca, err = x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.WriteFile(p.path("ca.pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	p.serial = 1
+
+	return p, nil
+}
+
+// issue writes a certificate signed by the CA and its key to <name>.pem and <name>-key.pem.
+func (p *testPKI) issue(name string, usage x509.ExtKeyUsage) (*big.Int, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	p.serial++
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(p.serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, p.ca, &key.PublicKey, p.caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.WriteFile(p.path(name+"-key.pem"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.WriteFile(p.path(name+".pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	// make sure the modification time changes when the certificate is replaced
+	mt := time.Now().Add(time.Duration(p.serial) * time.Second)
+	err = os.Chtimes(p.path(name+".pem"), mt, mt)
+	if err != nil {
+		return nil, err
+	}
+
+	return tmpl.SerialNumber, nil
+}
+
+func startTLSServer(ctx context.Context, mutual bool) error {
+	s := getState(ctx)
+
+	pki, err := newTestPKI(ctx)
+	if err != nil {
+		return fmt.Errorf("could not create test PKI: %w", err)
+	}
+	s.pki = pki
+
+	_, err = pki.issue("server", x509.ExtKeyUsageServerAuth)
+	if err != nil {
+		return err
+	}
+
+	clientCAFile := ""
+	if mutual {
+		clientCAFile = pki.path("ca.pem")
+	}
+
+	cfg, err := server.NewTLSConfig(logr.Discard(), pki.path("server.pem"), pki.path("server-key.pem"), clientCAFile)
+	if err != nil {
+		return err
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+
+	hs := &http.Server{Handler: s.server, ErrorLog: log.New(io.Discard, "", 0)}
+	go hs.Serve(tls.NewListener(l, cfg))
+	go func() {
+		<-ctx.Done()
+		hs.Close()
+	}()
+
+	s.serverBaseURL = fmt.Sprintf("https://%s", l.Addr().String())
+
+	gl, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+
+	gs := grpc.NewServer(grpc.Creds(credentials.NewTLS(cfg)))
+	s.server.RegisterGRPC(gs)
+	go gs.Serve(gl)
+	go func() {
+		<-ctx.Done()
+		gs.Stop()
+	}()
+
+	s.grpcAddr = gl.Addr().String()
+
+	return nil
+}
+
+func aServerWithTLS(ctx context.Context) error {
+	return startTLSServer(ctx, false)
+}
+
+func aServerWithMutualTLS(ctx context.Context) error {
+	return startTLSServer(ctx, true)
+}
+
+func useTLSClient(ctx context.Context, certFile, keyFile string) error {
+	s := getState(ctx)
+	cfg, err := client.LoadTLSConfig(s.pki.path("ca.pem"), certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	s.client, err = client.New(s.serverBaseURL, client.WithTLSConfig(cfg))
+	return err
+}
+
+func aClientTrustingTheTestCA(ctx context.Context) error {
+	return useTLSClient(ctx, "", "")
+}
+
+func aClientWithAClientCertificate(ctx context.Context) error {
+	s := getState(ctx)
+	_, err := s.pki.issue("client", x509.ExtKeyUsageClientAuth)
+	if err != nil {
+		return err
+	}
+	return useTLSClient(ctx, s.pki.path("client.pem"), s.pki.path("client-key.pem"))
+}
+
+func theServerCertificateIsReplaced(ctx context.Context) error {
+	s := getState(ctx)
+	_, err := s.pki.issue("server", x509.ExtKeyUsageServerAuth)
+	return err
+}
+
+func theServerShouldPresentTheNewCertificate(ctx context.Context) error {
+	s := getState(ctx)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(s.pki.ca)
+
+	conn, err := tls.Dial("tcp", s.serverBaseURL[len("https://"):], &tls.Config{RootCAs: roots})
+	if err != nil {
+		return fmt.Errorf("could not connect: %w", err)
+	}
+	defer conn.Close()
+
+	serial := conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+	if serial != s.pki.serial {
+		return fmt.Errorf("expected certificate with serial %d, got %d", s.pki.serial, serial)
+	}
+
+	return nil
+}
+
+func aFollowerForwardingWritesWithAClientCertificate(ctx context.Context) error {
+	s := getState(ctx)
+
+	_, err := s.pki.issue("follower", x509.ExtKeyUsageClientAuth)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := client.LoadTLSConfig(s.pki.path("ca.pem"), s.pki.path("follower.pem"), s.pki.path("follower-key.pem"))
+	if err != nil {
+		return err
+	}
+
+	leaderURL, err := url.Parse(s.serverBaseURL)
+	if err != nil {
+		return err
+	}
+
+	rig, err := testrig.StartServer(ctx, logr.FromContextOrDiscard(ctx), server.WithLeader(leaderURL, true), server.WithLeaderTLSConfig(cfg))
+	if err != nil {
+		return fmt.Errorf("could not start follower: %w", err)
+	}
+
+	s.follower, err = client.New(rig.URL)
+	return err
+}
+
+// clientCertificateTLSConfig returns the TLS configuration of the client issued by aClientWithAClientCertificate.
+func clientCertificateTLSConfig(ctx context.Context) (*tls.Config, error) {
+	s := getState(ctx)
+	return client.LoadTLSConfig(s.pki.path("ca.pem"), s.pki.path("client.pem"), s.pki.path("client-key.pem"))
+}
+
+func iHaveAGRPCConnectionWithTheClientCertificate(ctx context.Context) error {
+	s := getState(ctx)
+
+	cfg, err := clientCertificateTLSConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	conn, err := grpc.DialContext(ctx, s.grpcAddr, grpc.WithTransportCredentials(credentials.NewTLS(cfg)))
+	if err != nil {
+		return fmt.Errorf("could not dial grpc: %w", err)
+	}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+	s.grpcClient = eventbufferpb.NewEventBufferClient(conn)
+	return nil
+}
+
+func theServerShouldNegotiateHTTP2WithTheClientCertificate(ctx context.Context) error {
+	s := getState(ctx)
+
+	cfg, err := clientCertificateTLSConfig(ctx)
+	if err != nil {
+		return err
+	}
+	cfg.NextProtos = []string{"h2"}
+
+	for _, addr := range []string{s.serverBaseURL[len("https://"):], s.grpcAddr} {
+		conn, err := tls.Dial("tcp", addr, cfg)
+		if err != nil {
+			return fmt.Errorf("could not connect to %s: %w", addr, err)
+		}
+		protocol := conn.ConnectionState().NegotiatedProtocol
+		conn.Close()
+		if protocol != "h2" {
+			return fmt.Errorf("expected %s to negotiate h2, got %q", addr, protocol)
+		}
+	}
+
+	return nil
+}
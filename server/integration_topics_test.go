@@ -0,0 +1,96 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cucumber/godog"
+	"github.com/google/go-cmp/cmp"
+)
+
+func initializeTopicSteps(ctx *godog.ScenarioContext) {
+	ctx.Step(`^a topic named "([^"]*)"$`, aTopicNamed)
+	ctx.Step(`^I send an event "([^"]*)" to the topic "([^"]*)"$`, iSendAnEventToTheTopic)
+	ctx.Step(`^polling the topic "([^"]*)" should return "([^"]*)"$`, pollingTheTopicShouldReturn)
+	ctx.Step(`^polling the default topic should return "([^"]*)"$`, pollingTheDefaultTopicShouldReturn)
+	ctx.Step(`^I list the topics$`, iListTheTopics)
+	ctx.Step(`^the topics should be "([^"]*)"$`, theTopicsShouldBe)
+	ctx.Step(`^I delete the topic "([^"]*)"$`, iDeleteTheTopic)
+	ctx.Step(`^sending should have failed$`, sendingShouldHaveFailed)
+}
+
+func aTopicNamed(ctx context.Context, name string) error {
+	s := getState(ctx)
+	return s.client.CreateTopic(ctx, name)
+}
+
+func iSendAnEventToTheTopic(ctx context.Context, evt, topic string) error {
+	s := getState(ctx)
+	published, err := s.client.Topic(topic).SendEvents(ctx, []any{evt})
+	s.sendErr = err
+	if err == nil {
+		s.published = published
+	}
+	return nil
+}
+
+func pollingTheTopicShouldReturn(ctx context.Context, topic, expected string) error {
+	s := getState(ctx)
+	evts := []string{}
+	_, err := s.client.Topic(topic).PollForEvents(ctx, "", 100, &evts)
+	if err != nil {
+		return fmt.Errorf("failed polling for events: %w", err)
+	}
+	d := cmp.Diff(evts, strings.Split(expected, ","))
+	if d != "" {
+		return fmt.Errorf("unexpected poll result:\n%s", d)
+	}
+	return nil
+}
+
+func pollingTheDefaultTopicShouldReturn(ctx context.Context, expected string) error {
+	s := getState(ctx)
+	evts := []string{}
+	_, err := s.client.PollForEvents(ctx, "", 100, &evts)
+	if err != nil {
+		return fmt.Errorf("failed polling for events: %w", err)
+	}
+	d := cmp.Diff(evts, strings.Split(expected, ","))
+	if d != "" {
+		return fmt.Errorf("unexpected poll result:\n%s", d)
+	}
+	return nil
+}
+
+func iListTheTopics(ctx context.Context) error {
+	s := getState(ctx)
+	topics, err := s.client.ListTopics(ctx)
+	if err != nil {
+		return fmt.Errorf("could not list topics: %w", err)
+	}
+	s.topics = topics
+	return nil
+}
+
+func theTopicsShouldBe(ctx context.Context, expected string) error {
+	s := getState(ctx)
+	d := cmp.Diff(s.topics, strings.Split(expected, ","))
+	if d != "" {
+		return fmt.Errorf("unexpected topics:\n%s", d)
+	}
+	return nil
+}
+
+func iDeleteTheTopic(ctx context.Context, name string) error {
+	s := getState(ctx)
+	return s.client.DeleteTopic(ctx, name)
+}
+
+func sendingShouldHaveFailed(ctx context.Context) error {
+	s := getState(ctx)
+	if s.sendErr == nil {
+		return fmt.Errorf("expected sending to fail")
+	}
+	return nil
+}
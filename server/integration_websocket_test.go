@@ -0,0 +1,116 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/google/go-cmp/cmp"
+	"github.com/gorilla/websocket"
+)
+
+type wsMessage struct {
+	Type   string              `json:"type"`
+	IDs    []string            `json:"ids,omitempty"`
+	Events [][]json.RawMessage `json:"events,omitempty"`
+	Error  string              `json:"error,omitempty"`
+}
+
+func initializeWebSocketSteps(ctx *godog.ScenarioContext) {
+	ctx.Step(`^I have a websocket connection$`, iHaveAWebsocketConnection)
+	ctx.Step(`^I publish "([^"]*)" over the websocket$`, iPublishOverTheWebsocket)
+	ctx.Step(`^the websocket should confirm the published event$`, theWebsocketShouldConfirmThePublishedEvent)
+	ctx.Step(`^I subscribe over the websocket$`, iSubscribeOverTheWebsocket)
+	ctx.Step(`^the websocket should deliver "([^"]*)"$`, theWebsocketShouldDeliver)
+}
+
+func iHaveAWebsocketConnection(ctx context.Context) error {
+	s := getState(ctx)
+	wsURL := "ws" + strings.TrimPrefix(s.serverBaseURL, "http") + "/events"
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, nil)
+	if err != nil {
+		return fmt.Errorf("could not dial websocket: %w", err)
+	}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+	s.wsConn = conn
+	return nil
+}
+
+func readWebSocketMessage(ctx context.Context) (wsMessage, error) {
+	s := getState(ctx)
+	msg := wsMessage{}
+	s.wsConn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	err := s.wsConn.ReadJSON(&msg)
+	if err != nil {
+		return msg, fmt.Errorf("could not read websocket message: %w", err)
+	}
+	if msg.Type == "error" {
+		return msg, fmt.Errorf("websocket error: %s", msg.Error)
+	}
+	return msg, nil
+}
+
+func iPublishOverTheWebsocket(ctx context.Context, evt string) error {
+	s := getState(ctx)
+	return s.wsConn.WriteJSON(map[string]any{"type": "publish", "events": []any{evt}})
+}
+
+func theWebsocketShouldConfirmThePublishedEvent(ctx context.Context) error {
+	msg, err := readWebSocketMessage(ctx)
+	if err != nil {
+		return err
+	}
+	if msg.Type != "published" || len(msg.IDs) != 1 {
+		return fmt.Errorf("unexpected message %#v", msg)
+	}
+	return nil
+}
+
+func iSubscribeOverTheWebsocket(ctx context.Context) error {
+	s := getState(ctx)
+	err := s.wsConn.WriteJSON(map[string]any{"type": "subscribe"})
+	if err != nil {
+		return err
+	}
+	msg, err := readWebSocketMessage(ctx)
+	if err != nil {
+		return err
+	}
+	if msg.Type != "subscribed" {
+		return fmt.Errorf("unexpected message %#v", msg)
+	}
+	return nil
+}
+
+func theWebsocketShouldDeliver(ctx context.Context, expected string) error {
+	expectedEvents := strings.Split(expected, ",")
+	received := []string{}
+	for len(received) < len(expectedEvents) {
+		msg, err := readWebSocketMessage(ctx)
+		if err != nil {
+			return err
+		}
+		if msg.Type != "events" {
+			return fmt.Errorf("unexpected message %#v", msg)
+		}
+		for _, e := range msg.Events {
+			var evt string
+			err = json.Unmarshal(e[1], &evt)
+			if err != nil {
+				return fmt.Errorf("could not unmarshal event: %w", err)
+			}
+			received = append(received, evt)
+		}
+	}
+	d := cmp.Diff(received, expectedEvents)
+	if d != "" {
+		return fmt.Errorf("unexpected events:\n%s", d)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/draganm/bolted"
+	"github.com/gofrs/uuid"
+	"github.com/gorilla/mux"
+)
+
+// maxLookupIDs is the maximal number of events looked up by a single request.
+const maxLookupIDs = maxLimit
+
+var errEventNotFound = errors.New("event not found")
+
+func validateEventID(id string) error {
+	_, err := uuid.FromString(id)
+	if err != nil {
+		return fmt.Errorf("invalid event ID %q", id)
+	}
+	return nil
+}
+
+// getEvent returns the event of the topic with the given ID.
+func getEvent(tx bolted.SugaredReadTx, topic, id string) (event, error) {
+	topicPath := topicEventsPath(topic)
+	if !tx.Exists(topicPath) {
+		return event{}, errTopicNotFound
+	}
+	p := topicPath.Append(id)
+	if !tx.Exists(p) {
+		return event{}, errEventNotFound
+	}
+	return decodeEvent(id, tx.Get(p))
+}
+
+func (s *Server) getEvent(w http.ResponseWriter, r *http.Request) {
+	log := s.log.WithValues("method", r.Method, "path", r.URL.Path)
+
+	topic, err := requestTopic(r)
+	if err != nil {
+		log.Error(err, "invalid topic")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	err = validateEventID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var e event
+	err = bolted.SugaredRead(s.db, func(tx bolted.SugaredReadTx) (err error) {
+		e, err = getEvent(tx, topic, id)
+		return err
+	})
+
+	if errors.Is(err, errTopicNotFound) {
+		http.Error(w, fmt.Errorf("topic %s: %w", topic, err).Error(), http.StatusNotFound)
+		return
+	}
+
+	if errors.Is(err, errEventNotFound) {
+		http.Error(w, fmt.Errorf("event %s: %w", id, err).Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		log.Error(err, "could not read event", "topic", topic, "id", id)
+		http.Error(w, fmt.Errorf("could not read event: %w", err).Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(eventEnvelope(e))
+}
+
+type lookupRequest struct {
+	IDs []string `json:"ids"`
+}
+
+type lookupResponse struct {
+	// Events are the found events in the order of the requested IDs.
+	Events []eventEnvelope `json:"events"`
+	// Missing are the requested IDs of events which don't exist.
+	Missing []string `json:"missing"`
+}
+
+func (s *Server) lookupEvents(w http.ResponseWriter, r *http.Request) {
+	log := s.log.WithValues("method", r.Method, "path", r.URL.Path)
+
+	topic, err := requestTopic(r)
+	if err != nil {
+		log.Error(err, "invalid topic")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req := lookupRequest{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Error(err, "could not decode request")
+		http.Error(w, fmt.Errorf("could not decode request: %w", err).Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(req.IDs) > maxLookupIDs {
+		http.Error(w, fmt.Sprintf("requested %d events, at most %d are allowed", len(req.IDs), maxLookupIDs), http.StatusBadRequest)
+		return
+	}
+
+	for _, id := range req.IDs {
+		err = validateEventID(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	resp := lookupResponse{Events: []eventEnvelope{}, Missing: []string{}}
+	err = bolted.SugaredRead(s.db, func(tx bolted.SugaredReadTx) error {
+		for _, id := range req.IDs {
+			e, err := getEvent(tx, topic, id)
+			if errors.Is(err, errEventNotFound) {
+				resp.Missing = append(resp.Missing, id)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			resp.Events = append(resp.Events, eventEnvelope(e))
+		}
+		return nil
+	})
+
+	if errors.Is(err, errTopicNotFound) {
+		http.Error(w, fmt.Errorf("topic %s: %w", topic, err).Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		log.Error(err, "could not read events", "topic", topic)
+		http.Error(w, fmt.Errorf("could not read events: %w", err).Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"crypto/tls"
+	"net/url"
+	"time"
+)
+
+// Option configures optional behaviour of the Server.
+type Option func(*Server)
+
+// WithIdempotencyWindow sets how long idempotency keys of published events are remembered.
+func WithIdempotencyWindow(window time.Duration) Option {
+	return func(s *Server) {
+		s.idempotencyWindow = window
+	}
+}
+
+// WithMaxEvents limits the number of events retained per topic.
+// Zero means no limit.
+func WithMaxEvents(maxEvents int64) Option {
+	return func(s *Server) {
+		s.maxEvents = maxEvents
+	}
+}
+
+// WithMaxBytes limits the number of bytes occupied by the events of each topic.
+// Zero means no limit.
+func WithMaxBytes(maxBytes int64) Option {
+	return func(s *Server) {
+		s.maxBytes = maxBytes
+	}
+}
+
+// WithRetentionOnWrite enforces the count and size limits when events are published,
+// instead of only when the buffer is pruned.
+func WithRetentionOnWrite(enabled bool) Option {
+	return func(s *Server) {
+		s.retentionOnWrite = enabled
+	}
+}
+
+// WithConsumerAwareRetention keeps events that have not been committed by all consumer groups
+// of the topic when pruning by age, until they are older than maxAge.
+// Zero disables consumer-aware retention.
+func WithConsumerAwareRetention(maxAge time.Duration) Option {
+	return func(s *Server) {
+		s.consumerRetentionMaxAge = maxAge
+	}
+}
+
+// WithCompaction enables key-based compaction of topics which don't configure it themselves.
+func WithCompaction(enabled bool) Option {
+	return func(s *Server) {
+		s.compaction = enabled
+	}
+}
+
+// WithAuthenticators requires requests to carry a bearer token accepted by one of the authenticators.
+// Without authenticators all requests are permitted.
+func WithAuthenticators(authenticators ...Authenticator) Option {
+	return func(s *Server) {
+		s.authenticators = append(s.authenticators, authenticators...)
+	}
+}
+
+// WithLeader makes the server a read-only follower of the leader, see Server.Follow.
+// HTTP writes are forwarded to the leader if forwardWrites is set, otherwise they are rejected.
+// Publishes over gRPC and WebSocket are always rejected by followers, clients have to send them to the leader.
+func WithLeader(leaderURL *url.URL, forwardWrites bool) Option {
+	return func(s *Server) {
+		s.leaderURL = leaderURL
+		s.forwardWrites = forwardWrites
+	}
+}
+
+// WithFollowRefreshInterval sets how often a follower refreshes the topics, consumer group offsets
+// and audit records of the leader. Events are replicated as they are published.
+func WithFollowRefreshInterval(interval time.Duration) Option {
+	return func(s *Server) {
+		s.followRefreshInterval = interval
+	}
+}
+
+// WithLeaderTLSConfig sets the TLS configuration of connections forwarding writes to the leader,
+// it should match the configuration of the client replicating the leader.
+func WithLeaderTLSConfig(cfg *tls.Config) Option {
+	return func(s *Server) {
+		s.leaderTLSConfig = cfg
+	}
+}
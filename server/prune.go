@@ -6,57 +6,225 @@ import (
 
 	"github.com/draganm/bolted"
 	"github.com/gofrs/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
 const batchSize = 10000
 
+const (
+	pruneReasonAge   = "age"
+	pruneReasonCount = "count"
+	pruneReasonBytes = "bytes"
+	// pruneReasonMaxAge is used for events deleted by consumer-aware retention
+	// although they were not yet committed by all consumer groups.
+	pruneReasonMaxAge = "max-age"
+)
+
+var prunedEvents = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "event_buffer_pruned_events_total",
+		Help: "Number of events deleted by retention, by the retention limit that caused the deletion.",
+	},
+	[]string{"topic", "reason"},
+)
+
 func (s Server) Prune(cutoffTime time.Time) (err error) {
 
+	var topics []string
+	err = bolted.SugaredRead(s.db, func(tx bolted.SugaredReadTx) error {
+		topics = topicNames(tx)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("could not list topics: %w", err)
+	}
+
+	for _, topic := range topics {
+		err = s.pruneTopic(topic, cutoffTime)
+		if err != nil {
+			return fmt.Errorf("could not prune topic %s: %w", topic, err)
+		}
+		err = s.pruneIdempotencyKeys(topic, time.Now())
+		if err != nil {
+			return fmt.Errorf("could not prune idempotency keys of topic %s: %w", topic, err)
+		}
+	}
+
+	return nil
+}
+
+func (s Server) pruneTopic(topic string, cutoffTime time.Time) (err error) {
+
+	topicPath := topicEventsPath(topic)
 	eventsDeleted := true
 
+	var maxAgeCutoff time.Time
+	if s.consumerRetentionMaxAge > 0 {
+		maxAgeCutoff = time.Now().Add(-s.consumerRetentionMaxAge)
+	}
+
+	var plan *compaction
+	err = bolted.SugaredRead(s.db, func(tx bolted.SugaredReadTx) error {
+		if !tx.Exists(topicPath) {
+			return nil
+		}
+		compacted, err := s.topicCompacted(tx, topic)
+		if err != nil || !compacted {
+			return err
+		}
+		plan, err = planCompaction(tx, topic)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("could not plan compaction: %w", err)
+	}
+
 	for eventsDeleted {
+		deleted := uint64(0)
+		var pruned map[string]int
 		err = bolted.SugaredWrite(s.db, func(tx bolted.SugaredWriteTx) (err error) {
-			toDelete := []string{}
-			defer func() {
-				if err == nil {
-					s.log.Info("pruned state events", "count", len(toDelete))
-				}
-			}()
-			it := tx.Iterator(eventsPath)
-			for ; !it.IsDone(); it.Next() {
-				id, err := uuid.FromString(it.GetKey())
-				if err != nil {
-					return fmt.Errorf("could not parse uuid %s: %w", it.GetKey(), err)
-				}
-
-				ts, err := uuid.TimestampFromV6(id)
-				if err != nil {
-					return fmt.Errorf("could not get uuid timestamp: %w", err)
-				}
-
-				t, err := ts.Time()
-				if err != nil {
-					return fmt.Errorf("could not get time from uuid timestamp: %w", err)
-				}
-
-				if !t.Before(cutoffTime) {
-					break
-				}
-				toDelete = append(toDelete, it.GetKey())
-
-				if len(toDelete) >= batchSize {
-					break
-				}
+			if !tx.Exists(topicPath) {
+				// topic was deleted in the meantime
+				return nil
+			}
 
+			sizeBefore := tx.Size(topicPath)
+
+			max := batchSize
+			var retained map[string]bool
+			compactedEvents := 0
+			if plan != nil {
+				retained = plan.retained
+				compactedEvents = plan.compact(tx, topic, max)
+				max -= compactedEvents
 			}
 
-			eventsDeleted = len(toDelete) > 0
-			for _, id := range toDelete {
-				tx.Delete(eventsPath.Append(id))
+			pruned, err = s.enforceRetention(tx, topic, cutoffTime, maxAgeCutoff, max, retained)
+			if err != nil {
+				return err
+			}
+			if compactedEvents > 0 {
+				pruned[pruneReasonCompacted] = compactedEvents
 			}
+
+			deleted = sizeBefore - tx.Size(topicPath)
 			return nil
 		})
+
+		if err == nil {
+			countPruned(topic, pruned)
+			s.log.Info("pruned state events", "topic", topic, "count", deleted)
+		}
+
+		eventsDeleted = err == nil && deleted > 0
 	}
 
 	return
 }
+
+// countPruned adds the numbers of events deleted by reason to the pruned events metric,
+// once the transaction deleting them has been committed.
+func countPruned(topic string, pruned map[string]int) {
+	for reason, n := range pruned {
+		prunedEvents.WithLabelValues(topic, reason).Add(float64(n))
+	}
+}
+
+// enforceRetention deletes up to max oldest events of the topic which are older than the cutoff time
+// or exceed the maximal number of events or bytes of the topic.
+// With consumer-aware retention, events not yet committed by all consumer groups are
+// deleted by age only when they are older than maxAgeCutoff.
+// The retained events, the latest events of the keys of compacted topics (see compaction),
+// are not deleted by age, but they are deleted when the topic exceeds the maximal number of events or bytes.
+// It returns the numbers of deleted events by reason, see countPruned.
+func (s Server) enforceRetention(tx bolted.SugaredWriteTx, topic string, cutoffTime, maxAgeCutoff time.Time, max int, retained map[string]bool) (map[string]int, error) {
+	topicPath := topicEventsPath(topic)
+
+	count := int64(tx.Size(topicPath))
+	size := topicSize(tx, topic)
+
+	// events after the slowest committed offset are pinned
+	pinnedAfter := ""
+	if s.consumerRetentionMaxAge > 0 {
+		pinnedAfter = slowestOffset(tx, topic)
+	}
+
+	toDelete := []string{}
+	reasons := map[string]int{}
+
+	it := tx.Iterator(topicPath)
+	for ; !it.IsDone() && len(toDelete) < max; it.Next() {
+		t, err := eventTime(it.GetKey())
+		if err != nil {
+			return nil, err
+		}
+
+		pinned := pinnedAfter != "" && it.GetKey() > pinnedAfter
+		isRetained := retained[it.GetKey()]
+
+		var reason string
+		switch {
+		case t.Before(cutoffTime) && !pinned && !isRetained:
+			reason = pruneReasonAge
+		case t.Before(cutoffTime) && t.Before(maxAgeCutoff) && !isRetained:
+			reason = pruneReasonMaxAge
+		case s.maxEvents > 0 && count > s.maxEvents:
+			reason = pruneReasonCount
+		case s.maxBytes > 0 && size > s.maxBytes:
+			reason = pruneReasonBytes
+		default:
+			// events are ordered by time, so all newer events are retained
+		}
+
+		if reason == "" && isRetained {
+			// newer events may still be older than the cutoff time
+			continue
+		}
+
+		if reason == "" {
+			break
+		}
+
+		toDelete = append(toDelete, it.GetKey())
+		reasons[reason]++
+		count--
+		size -= eventSize(it.GetKey(), it.GetValue())
+	}
+
+	deleteEvents(tx, topic, toDelete)
+
+	return reasons, nil
+}
+
+// slowestOffset returns the smallest event ID committed by the consumer groups of the topic,
+// or an empty string if no group has committed yet.
+func slowestOffset(tx bolted.SugaredReadTx, topic string) string {
+	slowest := ""
+	for _, o := range groupOffsets(tx, topic) {
+		if slowest == "" || o.ID < slowest {
+			slowest = o.ID
+		}
+	}
+	return slowest
+}
+
+// eventTime returns the time encoded in the UUIDv6 event ID.
+func eventTime(eventID string) (time.Time, error) {
+	id, err := uuid.FromString(eventID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not parse uuid %s: %w", eventID, err)
+	}
+
+	ts, err := uuid.TimestampFromV6(id)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not get uuid timestamp: %w", err)
+	}
+
+	t, err := ts.Time()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not get time from uuid timestamp: %w", err)
+	}
+
+	return t, nil
+}
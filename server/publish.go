@@ -0,0 +1,301 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/draganm/bolted"
+	"github.com/gofrs/uuid"
+)
+
+// publishBatch is the object form of the POST /events request body.
+// The legacy form is a JSON array of event payloads.
+type publishBatch struct {
+	// IdempotencyKey identifies the whole batch. Retrying a batch with the same key
+	// returns the originally assigned IDs instead of appending the events again.
+	IdempotencyKey string         `json:"idempotencyKey,omitempty"`
+	Events         []publishEvent `json:"events"`
+}
+
+type publishEvent struct {
+	Payload     json.RawMessage   `json:"payload"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Key         string            `json:"key,omitempty"`
+	ContentType string            `json:"contentType,omitempty"`
+	// IdempotencyKey identifies a single event. Publishing an event with a known key
+	// returns the originally assigned ID instead of appending the event again.
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
+}
+
+const (
+	maxEventKeyLength    = 1024
+	maxEventHeaders      = 64
+	maxEventHeaderLength = 4096
+)
+
+type publishResponse struct {
+	Events []publishedEvent `json:"events"`
+}
+
+type publishedEvent struct {
+	ID string `json:"id"`
+	// Time is derived from the UUIDv6 event ID.
+	Time time.Time `json:"time"`
+}
+
+func newPublishResponse(ids []string) (publishResponse, error) {
+	events := make([]publishedEvent, len(ids))
+	for i, id := range ids {
+		t, err := eventTime(id)
+		if err != nil {
+			return publishResponse{}, err
+		}
+		events[i] = publishedEvent{ID: id, Time: t.UTC()}
+	}
+	return publishResponse{Events: events}, nil
+}
+
+// acceptsJSON returns true if the client asked for a response body.
+// Clients predating the response body do not send the accept header and get an empty response.
+func acceptsJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("accept"), "application/json")
+}
+
+func payloadsBatch(payloads []json.RawMessage) publishBatch {
+	events := make([]publishEvent, len(payloads))
+	for i, p := range payloads {
+		events[i] = publishEvent{Payload: p}
+	}
+	return publishBatch{Events: events}
+}
+
+func (b publishBatch) validate() error {
+	err := validateIdempotencyKey(b.IdempotencyKey)
+	if err != nil {
+		return err
+	}
+	for i, e := range b.Events {
+		if len(e.Payload) == 0 {
+			return fmt.Errorf("event %d has no payload", i)
+		}
+		err = validateIdempotencyKey(e.IdempotencyKey)
+		if err != nil {
+			return fmt.Errorf("event %d: %w", i, err)
+		}
+		if len(e.Key) > maxEventKeyLength {
+			return fmt.Errorf("event %d: key is longer than %d bytes", i, maxEventKeyLength)
+		}
+		if len(e.Headers) > maxEventHeaders {
+			return fmt.Errorf("event %d: more than %d headers", i, maxEventHeaders)
+		}
+		for k, v := range e.Headers {
+			if len(k)+len(v) > maxEventHeaderLength {
+				return fmt.Errorf("event %d: header %q is longer than %d bytes", i, k, maxEventHeaderLength)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Server) publishEvents(w http.ResponseWriter, r *http.Request) {
+
+	log := s.log.WithValues("method", r.Method, "path", r.URL.Path)
+
+	topic, err := requestTopic(r)
+	if err != nil {
+		log.Error(err, "invalid topic")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	body := json.RawMessage{}
+
+	err = json.NewDecoder(r.Body).Decode(&body)
+
+	if err != nil {
+		log.Error(err, "could not decode request")
+		http.Error(w, fmt.Errorf("could not decode request: %w", err).Error(), http.StatusBadRequest)
+		return
+	}
+
+	batch := publishBatch{}
+	objectForm := bytes.HasPrefix(bytes.TrimSpace(body), []byte("{"))
+
+	if objectForm {
+		err = json.Unmarshal(body, &batch)
+	} else {
+		events := []json.RawMessage{}
+		err = json.Unmarshal(body, &events)
+		batch = payloadsBatch(events)
+	}
+
+	if err != nil {
+		log.Error(err, "could not decode request")
+		http.Error(w, fmt.Errorf("could not decode request: %w", err).Error(), http.StatusBadRequest)
+		return
+	}
+
+	headerKey := r.Header.Get("idempotency-key")
+	if headerKey != "" {
+		if batch.IdempotencyKey != "" && batch.IdempotencyKey != headerKey {
+			http.Error(w, "idempotency key in the header does not match the key in the body", http.StatusBadRequest)
+			return
+		}
+		batch.IdempotencyKey = headerKey
+	}
+
+	err = batch.validate()
+	if err != nil {
+		log.Error(err, "invalid request")
+		http.Error(w, fmt.Errorf("invalid request: %w", err).Error(), http.StatusBadRequest)
+		return
+	}
+
+	ids, err := s.appendEvents(topic, batch)
+
+	ve := &schemaValidationError{}
+	if errors.As(err, &ve) {
+		log.Info("events rejected by schema", "topic", topic, "error", ve.Error())
+		writeSchemaViolations(w, ve)
+		return
+	}
+
+	if errors.Is(err, errTopicNotFound) {
+		log.Error(err, "could not store events", "topic", topic)
+		http.Error(w, fmt.Errorf("topic %s: %w", topic, err).Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		log.Error(err, "could not store events")
+		http.Error(w, fmt.Errorf("could not store events: %w", err).Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if objectForm || acceptsJSON(r) {
+		resp, err := newPublishResponse(ids)
+		if err != nil {
+			log.Error(err, "could not create response")
+			http.Error(w, fmt.Errorf("could not create response: %w", err).Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("content-type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+}
+
+// appendEvents stores the events at the end of the topic and returns their assigned IDs.
+// Events and batches with a known idempotency key are not stored again,
+// their originally assigned IDs are returned instead.
+func (s *Server) appendEvents(topic string, batch publishBatch) ([]string, error) {
+	topicPath := topicEventsPath(topic)
+	now := time.Now()
+	receivedAt := now.UTC()
+	expires := now.Add(s.idempotencyWindow)
+
+	var ids []string
+	var pruned map[string]int
+
+	err := bolted.SugaredWrite(s.db, func(tx bolted.SugaredWriteTx) error {
+		if !tx.Exists(topicPath) {
+			return errTopicNotFound
+		}
+
+		if batch.IdempotencyKey != "" {
+			rec, found, err := getIdempotencyRecord(tx, topic, idempotencyScopeBatch, batch.IdempotencyKey, now)
+			if err != nil {
+				return err
+			}
+			if found {
+				ids = rec.IDs
+				return nil
+			}
+		}
+
+		err := s.validateEvents(tx, topic, batch.Events)
+		if err != nil {
+			return err
+		}
+
+		ids = make([]string, len(batch.Events))
+		newIDs := []string{}
+		values := [][]byte{}
+
+		for i, ev := range batch.Events {
+			if ev.IdempotencyKey != "" {
+				rec, found, err := getIdempotencyRecord(tx, topic, idempotencyScopeEvent, ev.IdempotencyKey, now)
+				if err != nil {
+					return err
+				}
+				if found {
+					ids[i] = rec.IDs[0]
+					continue
+				}
+			}
+
+			// IDs are generated within the write transaction,
+			// so that the order of IDs matches the order of commits.
+			id, err := uuid.NewV6()
+			if err != nil {
+				return fmt.Errorf("could not generate UUID: %w", err)
+			}
+			ids[i] = id.String()
+
+			value, err := encodeEvent(ev.Payload, eventMeta{
+				Headers:     ev.Headers,
+				Key:         ev.Key,
+				ContentType: ev.ContentType,
+				ReceivedAt:  &receivedAt,
+			})
+			if err != nil {
+				return err
+			}
+
+			newIDs = append(newIDs, ids[i])
+			values = append(values, value)
+
+			if ev.IdempotencyKey != "" {
+				err = putIdempotencyRecord(tx, topic, idempotencyScopeEvent, ev.IdempotencyKey, idempotencyRecord{IDs: ids[i : i+1], Expires: expires})
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		putEvents(tx, topic, newIDs, values)
+
+		if batch.IdempotencyKey != "" {
+			err := putIdempotencyRecord(tx, topic, idempotencyScopeBatch, batch.IdempotencyKey, idempotencyRecord{IDs: ids, Expires: expires})
+			if err != nil {
+				return err
+			}
+		}
+
+		if s.retentionOnWrite {
+			// age based retention is left to the pruner
+			var err error
+			pruned, err = s.enforceRetention(tx, topic, time.Time{}, time.Time{}, math.MaxInt, nil)
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	countPruned(topic, pruned)
+
+	return ids, nil
+}
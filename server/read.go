@@ -0,0 +1,184 @@
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/draganm/bolted"
+	"github.com/draganm/bolted/dbpath"
+	"github.com/gofrs/uuid"
+)
+
+// readEvents returns up to limit events of the topic stored after the event with the given ID.
+// An empty after starts reading from the oldest event.
+func readEvents(tx bolted.SugaredReadTx, topicPath dbpath.Path, after string, limit int) ([]event, error) {
+	events, _, err := scanEvents(tx, topicPath, after, "", limit, nil)
+	return events, err
+}
+
+// maxScannedEvents limits the number of events examined by a single read transaction
+// when looking for events matching a filter.
+const maxScannedEvents = 10000
+
+// scanEvents returns up to limit events matching the filter, stored after the event with the given ID
+// and before the given ID. An empty before doesn't bound the events.
+// The returned cursor is the ID of the last examined event, or after if no events were examined.
+// Reading on from the cursor skips events which did not match the filter.
+func scanEvents(tx bolted.SugaredReadTx, topicPath dbpath.Path, after, before string, limit int, filter eventFilter) ([]event, string, error) {
+	if !tx.Exists(topicPath) {
+		return nil, after, errTopicNotFound
+	}
+	events := []event{}
+	cursor := after
+	it := tx.Iterator(topicPath)
+	if after != "" {
+		it.Seek(after)
+		if !it.IsDone() {
+			if it.GetKey() == after {
+				it.Next()
+			}
+		}
+	}
+	for scanned := 0; !it.IsDone() && len(events) < limit && scanned < maxScannedEvents; it.Next() {
+		if before != "" && it.GetKey() >= before {
+			break
+		}
+		e, err := decodeEvent(it.GetKey(), it.GetValue())
+		if err != nil {
+			return nil, after, err
+		}
+		scanned++
+		cursor = e.id
+		if filter.matches(e) {
+			events = append(events, e)
+		}
+	}
+	return events, cursor, nil
+}
+
+// waitForEvents returns up to limit events of the topic matching the filter, stored after the event with the given ID
+// and before the given ID, see scanEvents.
+// If there are no such events, it waits until new events are stored or the context is done.
+// The returned cursor is the ID of the last examined event, it is returned also when the context is done.
+func (s *Server) waitForEvents(ctx context.Context, topic, after, before string, limit int, filter eventFilter) ([]event, string, error) {
+	topicPath := topicEventsPath(topic)
+
+	changes, done := s.db.Observe(topicPath.ToMatcher().AppendAnyElementMatcher())
+	defer done()
+
+	cursor := after
+
+	for {
+		select {
+		case <-changes:
+		case <-ctx.Done():
+			return nil, cursor, ctx.Err()
+		}
+
+		for {
+			var events []event
+			var next string
+			err := bolted.SugaredRead(s.db, func(tx bolted.SugaredReadTx) (err error) {
+				events, next, err = scanEvents(tx, topicPath, cursor, before, limit, filter)
+				return err
+			})
+
+			if err != nil {
+				return nil, cursor, err
+			}
+
+			if len(events) > 0 {
+				return events, next, nil
+			}
+
+			if next == cursor {
+				// no new events, wait for the next change
+				break
+			}
+
+			// skip the events not matching the filter
+			cursor = next
+
+			if ctx.Err() != nil {
+				return nil, cursor, ctx.Err()
+			}
+		}
+	}
+}
+
+var errInvalidRequest = errors.New("invalid request")
+
+// timeID returns a key ordered after the IDs of all events created before t
+// and before the IDs of all events created at or after t.
+// It is not a valid UUID, the variant bits are cleared so that it can't be equal to an event ID.
+func timeID(t time.Time) string {
+	// UUID timestamps count 100ns intervals since the start of the Gregorian calendar
+	ts := uint64(0)
+	if secs := t.Unix() + uuidEpochOffset; secs >= 0 {
+		ts = uint64(secs)*10_000_000 + uint64(t.Nanosecond()/100)
+	}
+
+	var u uuid.UUID
+	binary.BigEndian.PutUint32(u[0:], uint32(ts>>28))
+	binary.BigEndian.PutUint16(u[4:], uint16(ts>>12))
+	binary.BigEndian.PutUint16(u[6:], uint16(ts&0xfff))
+	u.SetVersion(uuid.V6)
+	return u.String()
+}
+
+// uuidEpochOffset is the number of seconds from 1582-10-15, the start of UUID timestamps, to the Unix epoch.
+const uuidEpochOffset = 12219292800
+
+// parseTimeRange parses the since and until query parameters.
+func parseTimeRange(q url.Values) (since, until time.Time, err error) {
+	for _, p := range []struct {
+		name string
+		t    *time.Time
+	}{{"since", &since}, {"until", &until}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		*p.t, err = time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: could not parse %s: %s", errInvalidRequest, p.name, err.Error())
+		}
+	}
+
+	if !since.IsZero() && !until.IsZero() && !since.Before(until) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: since must be before until", errInvalidRequest)
+	}
+
+	return since, until, nil
+}
+
+// startPosition resolves the event ID after which reading starts.
+// It is either given explicitly or is the offset committed by the consumer group.
+func (s *Server) startPosition(topic, after, group string) (string, error) {
+	if group == "" {
+		return after, nil
+	}
+
+	if after != "" {
+		return "", fmt.Errorf("%w: after and group parameters are mutually exclusive", errInvalidRequest)
+	}
+
+	err := validateGroupName(group)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", errInvalidRequest, err.Error())
+	}
+
+	err = bolted.SugaredRead(s.db, func(tx bolted.SugaredReadTx) error {
+		after = committedOffset(tx, topic, group)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not read committed offset of group %s: %w", group, err)
+	}
+
+	return after, nil
+}
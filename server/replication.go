@@ -0,0 +1,490 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/draganm/bolted"
+	"github.com/draganm/bolted/dbpath"
+	"github.com/draganm/event-buffer/client"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// replicationCursorsPath maps topics to the ID of the last event replicated from the leader.
+// Events pruned locally are not replicated again.
+var replicationCursorsPath = dbpath.ToPath("replication-cursors")
+
+// replicationAuditCursorsPath maps topics to the ID of the last audit record of the leader applied by the follower.
+var replicationAuditCursorsPath = dbpath.ToPath("replication-audit-cursors")
+
+// pendingErasuresPath maps topics to deletions and redactions of events the follower has not replicated yet, by event ID.
+// Events read from the leader before they were erased there are replaced or skipped when they are stored.
+var pendingErasuresPath = dbpath.ToPath("pending-erasures")
+
+// defaultFollowRefreshInterval is how often a follower refreshes the topics, consumer group offsets
+// and audit records of the leader unless WithFollowRefreshInterval is used.
+const defaultFollowRefreshInterval = 5 * time.Second
+
+const replicationBatchSize = maxLimit
+
+var (
+	replicationLag = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "event_buffer_replication_lag_seconds",
+			Help: "Age of the oldest event of the leader not yet replicated, 0 when the follower has caught up. While the leader is unreachable, the time since it was last reached.",
+		},
+		[]string{"topic"},
+	)
+	replicatedEvents = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "event_buffer_replicated_events_total",
+			Help: "Number of events replicated from the leader.",
+		},
+		[]string{"topic"},
+	)
+)
+
+var errFollower = errors.New("writes are not accepted by a follower")
+
+// isFollower returns true if the server replicates another instance and doesn't accept writes.
+func (s *Server) isFollower() bool {
+	return s.leaderURL != nil
+}
+
+// writable rejects HTTP writes on a follower or forwards them to the leader.
+func (s *Server) writable(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.isFollower() {
+			h(w, r)
+			return
+		}
+
+		if s.forwardWrites {
+			s.leaderProxy.ServeHTTP(w, r)
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("%s, send them to %s", errFollower.Error(), s.leaderURL.String()), http.StatusMisdirectedRequest)
+	}
+}
+
+// Follow replicates the topics, events and consumer group offsets of the leader until the context is done.
+// Events are stored with the IDs assigned by the leader.
+// Deletions and redactions are replicated from the audit log of the leader, which requires the admin scope.
+// Topics are deleted when the leader doesn't find them, topics the follower is not permitted to read are kept.
+func (s *Server) Follow(ctx context.Context, leader *client.Client) error {
+	var mu sync.Mutex
+	running := map[string]context.CancelFunc{}
+
+	defer func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, cancel := range running {
+			cancel()
+		}
+	}()
+
+	// lastContact is when the replication lag of each topic was last measured at the leader
+	lastContact := map[string]time.Time{}
+
+	ticker := time.NewTicker(s.followRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		topics, err := leader.ListTopics(ctx)
+		if err != nil && ctx.Err() == nil {
+			s.log.Error(err, "could not list topics of the leader")
+			for topic, t := range lastContact {
+				replicationLag.WithLabelValues(topic).Set(time.Since(t).Seconds())
+			}
+		}
+
+		if err == nil {
+			leaderTopics := map[string]bool{}
+			for _, topic := range topics {
+				leaderTopics[topic] = true
+
+				mu.Lock()
+				_, isRunning := running[topic]
+				if !isRunning {
+					topicCtx, cancel := context.WithCancel(ctx)
+					running[topic] = cancel
+					go func(topic string) {
+						err := s.replicateTopic(topicCtx, leader.Topic(topic), topic)
+						if err != nil && topicCtx.Err() == nil {
+							s.log.Error(err, "replication of topic stopped", "topic", topic)
+						}
+						mu.Lock()
+						delete(running, topic)
+						mu.Unlock()
+						cancel()
+					}(topic)
+				}
+				mu.Unlock()
+
+				err = s.replicateGroupOffsets(ctx, leader.Topic(topic), topic)
+				if err != nil && ctx.Err() == nil {
+					s.log.Error(err, "could not replicate consumer group offsets", "topic", topic)
+				}
+
+				err = s.replicateErasures(ctx, leader.Topic(topic), topic)
+				if err != nil && ctx.Err() == nil {
+					s.log.Error(err, "could not replicate deletions and redactions", "topic", topic)
+				}
+
+				lag, err := s.replicationLag(ctx, leader.Topic(topic), topic)
+				if err != nil {
+					if ctx.Err() == nil {
+						s.log.Error(err, "could not measure replication lag", "topic", topic)
+					}
+					if _, found := lastContact[topic]; !found {
+						lastContact[topic] = time.Now()
+					}
+					lag = time.Since(lastContact[topic])
+				} else {
+					lastContact[topic] = time.Now()
+				}
+				replicationLag.WithLabelValues(topic).Set(lag.Seconds())
+			}
+
+			err = s.deleteTopicsMissingOn(ctx, leader, leaderTopics)
+			if err != nil && ctx.Err() == nil {
+				s.log.Error(err, "could not delete topics deleted on the leader")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) replicateTopic(ctx context.Context, leader *client.Client, topic string) error {
+	var cursor string
+	err := bolted.SugaredWrite(s.db, func(tx bolted.SugaredWriteTx) error {
+		if createTopic(tx, topic) {
+			s.log.Info("replicating new topic", "topic", topic)
+		}
+		if tx.Exists(replicationCursorsPath.Append(topic)) {
+			cursor = string(tx.Get(replicationCursorsPath.Append(topic)))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("could not read replication cursor: %w", err)
+	}
+
+	return leader.Subscribe(ctx, cursor, func(ctx context.Context, events []client.Event) error {
+		ids := make([]string, len(events))
+		values := make([][]byte, len(events))
+		for i, e := range events {
+			value, err := encodeReplicatedEvent(e)
+			if err != nil {
+				return err
+			}
+			ids[i] = e.ID
+			values[i] = value
+		}
+
+		last := ids[len(ids)-1]
+
+		var pruned map[string]int
+		err := bolted.SugaredWrite(s.db, func(tx bolted.SugaredWriteTx) error {
+			if !tx.Exists(topicEventsPath(topic)) {
+				return errTopicNotFound
+			}
+			ids, values, err := applyPendingErasures(tx, topic, ids, values)
+			if err != nil {
+				return err
+			}
+			putEvents(tx, topic, ids, values)
+			tx.Put(replicationCursorsPath.Append(topic), []byte(last))
+			if s.retentionOnWrite {
+				pruned, err = s.enforceRetention(tx, topic, time.Time{}, time.Time{}, math.MaxInt, nil)
+				return err
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("could not store replicated events: %w", err)
+		}
+
+		countPruned(topic, pruned)
+
+		replicatedEvents.WithLabelValues(topic).Add(float64(len(events)))
+
+		return nil
+	}, client.WithBatchSize(replicationBatchSize))
+}
+
+func encodeReplicatedEvent(e client.Event) ([]byte, error) {
+	meta := eventMeta{
+		Headers:     e.Headers,
+		Key:         e.Key,
+		ContentType: e.ContentType,
+	}
+	if !e.ReceivedAt.IsZero() {
+		receivedAt := e.ReceivedAt
+		meta.ReceivedAt = &receivedAt
+	}
+	return encodeEvent(e.Payload, meta)
+}
+
+// pendingErasure is a deletion or redaction of an event the follower has not replicated yet.
+type pendingErasure struct {
+	// Event is the redacted event, nil if the event was deleted.
+	Event []byte `json:"event,omitempty"`
+}
+
+// applyPendingErasures skips deleted events and replaces redacted events among the replicated events,
+// and drops the pending erasures of events up to the last replicated one.
+func applyPendingErasures(tx bolted.SugaredWriteTx, topic string, ids []string, values [][]byte) ([]string, [][]byte, error) {
+	p := pendingErasuresPath.Append(topic)
+	if !tx.Exists(p) {
+		return ids, values, nil
+	}
+
+	keptIDs := []string{}
+	keptValues := [][]byte{}
+	for i, id := range ids {
+		if !tx.Exists(p.Append(id)) {
+			keptIDs = append(keptIDs, id)
+			keptValues = append(keptValues, values[i])
+			continue
+		}
+		pe := pendingErasure{}
+		err := json.Unmarshal(tx.Get(p.Append(id)), &pe)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not decode pending erasure of event %s: %w", id, err)
+		}
+		if pe.Event != nil {
+			keptIDs = append(keptIDs, id)
+			keptValues = append(keptValues, pe.Event)
+		}
+	}
+
+	last := ids[len(ids)-1]
+	done := []string{}
+	for it := tx.Iterator(p); !it.IsDone() && it.GetKey() <= last; it.Next() {
+		done = append(done, it.GetKey())
+	}
+	for _, id := range done {
+		tx.Delete(p.Append(id))
+	}
+
+	return keptIDs, keptValues, nil
+}
+
+// replicateErasures applies the deletions and redactions of the topic's events recorded in the audit log of the leader
+// since the last applied record, and copies the audit records.
+func (s *Server) replicateErasures(ctx context.Context, leader *client.Client, topic string) error {
+	var cursor string
+	replicating := false
+	err := bolted.SugaredRead(s.db, func(tx bolted.SugaredReadTx) error {
+		replicating = tx.Exists(topicEventsPath(topic))
+		if tx.Exists(replicationAuditCursorsPath.Append(topic)) {
+			cursor = string(tx.Get(replicationAuditCursorsPath.Append(topic)))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("could not read audit cursor: %w", err)
+	}
+
+	if !replicating {
+		// the topic is created when its replication starts
+		return nil
+	}
+
+	for {
+		records, err := leader.AuditRecordsAfter(ctx, cursor, replicationBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, rec := range records {
+			err = s.applyErasure(ctx, leader, topic, rec)
+			if err != nil {
+				return fmt.Errorf("could not apply audit record %s: %w", rec.ID, err)
+			}
+			cursor = rec.ID
+		}
+
+		if len(records) < replicationBatchSize {
+			return nil
+		}
+	}
+}
+
+// applyErasure deletes or redacts the events affected by the audit record of the leader.
+// Redacted events are read from the leader, affected events which no longer exist there are deleted.
+func (s *Server) applyErasure(ctx context.Context, leader *client.Client, topic string, rec client.AuditRecord) error {
+	redacted := map[string][]byte{}
+	if rec.Operation == eraseOpRedact {
+		for start := 0; start < len(rec.Affected); start += maxLookupIDs {
+			end := start + maxLookupIDs
+			if end > len(rec.Affected) {
+				end = len(rec.Affected)
+			}
+			events, _, err := leader.GetEvents(ctx, rec.Affected[start:end])
+			if err != nil {
+				return fmt.Errorf("could not read redacted events: %w", err)
+			}
+			for _, e := range events {
+				value, err := encodeReplicatedEvent(e)
+				if err != nil {
+					return err
+				}
+				redacted[e.ID] = value
+			}
+		}
+	}
+
+	d, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("could not marshal audit record: %w", err)
+	}
+
+	return bolted.SugaredWrite(s.db, func(tx bolted.SugaredWriteTx) error {
+		topicPath := topicEventsPath(topic)
+		if !tx.Exists(topicPath) {
+			return errTopicNotFound
+		}
+
+		var cursor string
+		if tx.Exists(replicationCursorsPath.Append(topic)) {
+			cursor = string(tx.Get(replicationCursorsPath.Append(topic)))
+		}
+
+		pending := pendingErasuresPath.Append(topic)
+		toDelete := []string{}
+		ids := []string{}
+		values := [][]byte{}
+
+		for _, id := range rec.Affected {
+			value := redacted[id]
+			if id > cursor {
+				pe, err := json.Marshal(pendingErasure{Event: value})
+				if err != nil {
+					return err
+				}
+				if !tx.Exists(pending) {
+					tx.CreateMap(pending)
+				}
+				tx.Put(pending.Append(id), pe)
+				continue
+			}
+			if value == nil {
+				toDelete = append(toDelete, id)
+				continue
+			}
+			// events pruned by the follower stay deleted
+			if tx.Exists(topicPath.Append(id)) {
+				ids = append(ids, id)
+				values = append(values, value)
+			}
+		}
+
+		deleteEvents(tx, topic, toDelete)
+		putEvents(tx, topic, ids, values)
+
+		putAuditRecordData(tx, topic, rec.ID, d)
+		tx.Put(replicationAuditCursorsPath.Append(topic), []byte(rec.ID))
+		return nil
+	})
+}
+
+// replicationLag returns the age of the oldest event of the leader after the replication cursor of the topic,
+// zero if all events have been replicated.
+func (s *Server) replicationLag(ctx context.Context, leader *client.Client, topic string) (time.Duration, error) {
+	var cursor string
+	err := bolted.SugaredRead(s.db, func(tx bolted.SugaredReadTx) error {
+		if tx.Exists(replicationCursorsPath.Append(topic)) {
+			cursor = string(tx.Get(replicationCursorsPath.Append(topic)))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("could not read replication cursor: %w", err)
+	}
+
+	next, err := leader.ReadEvents(ctx, cursor, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(next) == 0 {
+		return 0, nil
+	}
+
+	return time.Since(next[0].Time), nil
+}
+
+func (s *Server) replicateGroupOffsets(ctx context.Context, leader *client.Client, topic string) error {
+	offsets, err := leader.GroupOffsets(ctx)
+	if err != nil {
+		return err
+	}
+
+	return bolted.SugaredWrite(s.db, func(tx bolted.SugaredWriteTx) error {
+		if !tx.Exists(topicEventsPath(topic)) {
+			return nil
+		}
+		p := topicGroupsPath(topic)
+		if !tx.Exists(p) {
+			tx.CreateMap(p)
+		}
+		for _, o := range offsets {
+			tx.Put(p.Append(o.Group), []byte(o.ID))
+		}
+		return nil
+	})
+}
+
+// deleteTopicsMissingOn deletes the local topics which are not listed by the leader and which the leader reports as not found.
+// Topics the follower is not permitted to read are not listed, but they are kept.
+func (s *Server) deleteTopicsMissingOn(ctx context.Context, leader *client.Client, leaderTopics map[string]bool) error {
+	var unlisted []string
+	err := bolted.SugaredRead(s.db, func(tx bolted.SugaredReadTx) error {
+		for _, topic := range topicNames(tx) {
+			if !leaderTopics[topic] && topic != DefaultTopic {
+				unlisted = append(unlisted, topic)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("could not list topics: %w", err)
+	}
+
+	for _, topic := range unlisted {
+		_, err = leader.GetTopic(ctx, topic)
+		se := &client.StatusError{}
+		if !errors.As(err, &se) || se.StatusCode != http.StatusNotFound {
+			continue
+		}
+
+		err = bolted.SugaredWrite(s.db, func(tx bolted.SugaredWriteTx) error {
+			if !tx.Exists(topicEventsPath(topic)) {
+				return nil
+			}
+			return deleteTopic(tx, topic)
+		})
+		if err != nil {
+			return fmt.Errorf("could not delete topic %s: %w", topic, err)
+		}
+		s.forgetSchemas(topic, "", true)
+		s.log.Info("deleted topic deleted on the leader", "topic", topic)
+	}
+
+	return nil
+}
@@ -0,0 +1,536 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/draganm/bolted"
+	"github.com/draganm/bolted/dbpath"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// schemasPath holds the registered JSON schemas of the topics as
+// schemas/<topic>/<subject>/<version>, where the subject is the event type
+// or topicSchemaSubject for schemas of all events of the topic.
+var schemasPath = dbpath.ToPath("schemas")
+
+// topicSchemaSubject is the subject of schemas applying to events without a type specific schema.
+// It can't collide with event types, which are validated like topic names.
+const topicSchemaSubject = "*"
+
+// eventTypeHeader is the event header selecting the schema of the event type.
+const eventTypeHeader = "type"
+
+const (
+	// compatibilityNone registers new versions without checks.
+	compatibilityNone = "none"
+	// compatibilityBackward requires that events valid against the previous version are valid against the new one.
+	compatibilityBackward = "backward"
+	// compatibilityForward requires that events valid against the new version are valid against the previous one.
+	compatibilityForward = "forward"
+	// compatibilityFull requires both backward and forward compatibility.
+	compatibilityFull = "full"
+)
+
+var errIncompatibleSchema = errors.New("incompatible schema")
+
+var errSchemaNotFound = errors.New("schema not found")
+
+type schemaVersion struct {
+	Topic string `json:"topic"`
+	// Type is the event type of the schema, empty for schemas of the whole topic.
+	Type          string          `json:"type,omitempty"`
+	Version       int             `json:"version"`
+	Compatibility string          `json:"compatibility"`
+	Created       time.Time       `json:"created"`
+	Schema        json.RawMessage `json:"schema"`
+}
+
+type schemaRegistration struct {
+	Type   string          `json:"type,omitempty"`
+	Schema json.RawMessage `json:"schema"`
+	// Compatibility defaults to the compatibility of the previous version, or none for the first version.
+	Compatibility string `json:"compatibility,omitempty"`
+}
+
+func (sr schemaRegistration) validate() error {
+	if sr.Type != "" && !topicNameRegexp.MatchString(sr.Type) {
+		return fmt.Errorf("invalid event type %q: must match %s", sr.Type, topicNameRegexp.String())
+	}
+	if len(sr.Schema) == 0 {
+		return errors.New("schema is missing")
+	}
+	switch sr.Compatibility {
+	case "", compatibilityNone, compatibilityBackward, compatibilityForward, compatibilityFull:
+	default:
+		return fmt.Errorf("unknown compatibility %q", sr.Compatibility)
+	}
+	return nil
+}
+
+func schemaSubjectPath(topic, eventType string) dbpath.Path {
+	subject := eventType
+	if subject == "" {
+		subject = topicSchemaSubject
+	}
+	return schemasPath.Append(topic, subject)
+}
+
+func schemaVersionKey(version int) string {
+	// zero padded, so that versions are ordered by the iterator
+	return fmt.Sprintf("%010d", version)
+}
+
+// latestSchema returns the latest version of the schema of the event type, or of the topic if the type is empty.
+func latestSchema(tx bolted.SugaredReadTx, topic, eventType string) (schemaVersion, bool, error) {
+	sv := schemaVersion{}
+	p := schemaSubjectPath(topic, eventType)
+	if !tx.Exists(p) {
+		return sv, false, nil
+	}
+	it := tx.Iterator(p)
+	it.Last()
+	if it.IsDone() {
+		return sv, false, nil
+	}
+	err := json.Unmarshal(it.GetValue(), &sv)
+	if err != nil {
+		return sv, false, fmt.Errorf("could not unmarshal schema %s version %s: %w", p.String(), it.GetKey(), err)
+	}
+	return sv, true, nil
+}
+
+// schemaVersions returns all versions of the topic's schemas, ordered by subject and version.
+// The type selects the versions of a single subject if only is set.
+func schemaVersions(tx bolted.SugaredReadTx, topic, eventType string, only bool) ([]schemaVersion, error) {
+	versions := []schemaVersion{}
+	topicPath := schemasPath.Append(topic)
+	if !tx.Exists(topicPath) {
+		return versions, nil
+	}
+
+	for subjects := tx.Iterator(topicPath); !subjects.IsDone(); subjects.Next() {
+		p := topicPath.Append(subjects.GetKey())
+		if only && !p.Equal(schemaSubjectPath(topic, eventType)) {
+			continue
+		}
+		for it := tx.Iterator(p); !it.IsDone(); it.Next() {
+			sv := schemaVersion{}
+			err := json.Unmarshal(it.GetValue(), &sv)
+			if err != nil {
+				return nil, fmt.Errorf("could not unmarshal schema %s version %s: %w", p.String(), it.GetKey(), err)
+			}
+			versions = append(versions, sv)
+		}
+	}
+
+	return versions, nil
+}
+
+// schemaCacheKey identifies the schema of an event type, or of the topic if the type is empty.
+type schemaCacheKey struct {
+	topic     string
+	eventType string
+}
+
+// compiledSchemaVersion is the compiled latest version of a schema.
+type compiledSchemaVersion struct {
+	schema   json.RawMessage
+	compiled *jsonschema.Schema
+}
+
+// compiledSchema returns the compiled schema version. The latest compiled version of each schema is cached,
+// the cache entry is replaced when a new version is used and removed when the schema or its topic is deleted.
+func (s *Server) compiledSchema(sv schemaVersion) (*jsonschema.Schema, error) {
+	key := schemaCacheKey{topic: sv.Topic, eventType: sv.Type}
+
+	cached, found := s.compiledSchemas.Load(key)
+	if found && bytes.Equal(cached.(compiledSchemaVersion).schema, sv.Schema) {
+		return cached.(compiledSchemaVersion).compiled, nil
+	}
+
+	compiled, err := compileSchema(sv.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	s.compiledSchemas.Store(key, compiledSchemaVersion{schema: sv.Schema, compiled: compiled})
+	return compiled, nil
+}
+
+// forgetSchemas removes the compiled schemas of the topic from the cache,
+// those of the event type only unless all is set.
+func (s *Server) forgetSchemas(topic, eventType string, all bool) {
+	s.compiledSchemas.Range(func(k, _ any) bool {
+		key := k.(schemaCacheKey)
+		if key.topic == topic && (all || key.eventType == eventType) {
+			s.compiledSchemas.Delete(k)
+		}
+		return true
+	})
+}
+
+// compileSchema compiles the JSON schema.
+func compileSchema(schema json.RawMessage) (*jsonschema.Schema, error) {
+	c := jsonschema.NewCompiler()
+	c.Draft = jsonschema.Draft2020
+	// schemas must be self-contained, loading references could read arbitrary files or URLs
+	c.LoadURL = func(s string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("could not load %s: references to other documents are not supported", s)
+	}
+
+	err := c.AddResource("schema.json", bytes.NewReader(schema))
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Compile("schema.json")
+}
+
+// schemaViolation describes why an event of a published batch does not match its schema.
+type schemaViolation struct {
+	// Index is the position of the event in the batch.
+	Index   int           `json:"index"`
+	Type    string        `json:"type,omitempty"`
+	Version int           `json:"version"`
+	Errors  []schemaError `json:"errors"`
+}
+
+type schemaError struct {
+	// InstanceLocation is the JSON pointer of the invalid value within the payload.
+	InstanceLocation string `json:"instanceLocation"`
+	// KeywordLocation is the JSON pointer of the failed keyword within the schema.
+	KeywordLocation string `json:"keywordLocation"`
+	Message         string `json:"message"`
+}
+
+// schemaValidationError is returned when events of a batch don't match their schemas.
+type schemaValidationError struct {
+	Violations []schemaViolation `json:"violations"`
+}
+
+func (e *schemaValidationError) Error() string {
+	v := e.Violations[0]
+	msg := fmt.Sprintf("%d events do not match their schema, event %d", len(e.Violations), v.Index)
+	if len(v.Errors) > 0 {
+		msg = fmt.Sprintf("%s: %s: %s", msg, v.Errors[0].InstanceLocation, v.Errors[0].Message)
+	}
+	return msg
+}
+
+func (e *schemaValidationError) Unwrap() error {
+	return errInvalidRequest
+}
+
+// leafErrors flattens the validation error into the errors that caused it.
+func leafErrors(ve *jsonschema.ValidationError) []schemaError {
+	if len(ve.Causes) == 0 {
+		return []schemaError{{
+			InstanceLocation: ve.InstanceLocation,
+			KeywordLocation:  ve.KeywordLocation,
+			Message:          ve.Message,
+		}}
+	}
+	errs := []schemaError{}
+	for _, c := range ve.Causes {
+		errs = append(errs, leafErrors(c)...)
+	}
+	return errs
+}
+
+// validateEvents validates the payloads of the events against the latest version of the schema of their type,
+// falling back to the schema of the topic. Events without a schema and tombstones are not validated.
+// It returns a *schemaValidationError listing all invalid events.
+func (s *Server) validateEvents(tx bolted.SugaredReadTx, topic string, events []publishEvent) error {
+	if !tx.Exists(schemasPath.Append(topic)) {
+		return nil
+	}
+
+	violations := []schemaViolation{}
+
+	for i, e := range events {
+		if isTombstone(event{payload: e.Payload, meta: eventMeta{Key: e.Key}}) {
+			// tombstones delete keys of compacted topics, they have no payload to validate
+			continue
+		}
+
+		eventType := e.Headers[eventTypeHeader]
+
+		sv, found, err := latestSchema(tx, topic, eventType)
+		if err != nil {
+			return err
+		}
+
+		if !found && eventType != "" {
+			sv, found, err = latestSchema(tx, topic, "")
+			if err != nil {
+				return err
+			}
+		}
+
+		if !found {
+			continue
+		}
+
+		schema, err := s.compiledSchema(sv)
+		if err != nil {
+			return fmt.Errorf("could not compile schema %s version %d: %w", sv.Type, sv.Version, err)
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(e.Payload))
+		dec.UseNumber()
+		var v any
+		err = dec.Decode(&v)
+		if err != nil {
+			return fmt.Errorf("%w: event %d: could not decode payload: %s", errInvalidRequest, i, err.Error())
+		}
+
+		err = schema.Validate(v)
+		ve := &jsonschema.ValidationError{}
+		if errors.As(err, &ve) {
+			violations = append(violations, schemaViolation{
+				Index:   i,
+				Type:    sv.Type,
+				Version: sv.Version,
+				Errors:  leafErrors(ve),
+			})
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not validate event %d: %w", i, err)
+		}
+	}
+
+	if len(violations) > 0 {
+		return &schemaValidationError{Violations: violations}
+	}
+
+	return nil
+}
+
+// writeSchemaViolations responds with the details of the events rejected by their schemas.
+func writeSchemaViolations(w http.ResponseWriter, ve *schemaValidationError) {
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(struct {
+		Error      string            `json:"error"`
+		Violations []schemaViolation `json:"violations"`
+	}{
+		Error:      ve.Error(),
+		Violations: ve.Violations,
+	})
+}
+
+func (s *Server) registerSchema(w http.ResponseWriter, r *http.Request) {
+	log := s.log.WithValues("method", r.Method, "path", r.URL.Path)
+
+	topic, err := requestTopic(r)
+	if err != nil {
+		log.Error(err, "invalid topic")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reg := schemaRegistration{}
+	err = json.NewDecoder(r.Body).Decode(&reg)
+	if err != nil {
+		log.Error(err, "could not decode request")
+		http.Error(w, fmt.Errorf("could not decode request: %w", err).Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = reg.validate()
+	if err != nil {
+		log.Error(err, "invalid request")
+		http.Error(w, fmt.Errorf("invalid request: %w", err).Error(), http.StatusBadRequest)
+		return
+	}
+
+	compacted := &bytes.Buffer{}
+	err = json.Compact(compacted, reg.Schema)
+	if err != nil {
+		http.Error(w, fmt.Errorf("invalid schema: %w", err).Error(), http.StatusBadRequest)
+		return
+	}
+	reg.Schema = compacted.Bytes()
+
+	_, err = compileSchema(reg.Schema)
+	if err != nil {
+		http.Error(w, fmt.Errorf("invalid schema: %w", err).Error(), http.StatusBadRequest)
+		return
+	}
+
+	registered := false
+	sv := schemaVersion{}
+	err = bolted.SugaredWrite(s.db, func(tx bolted.SugaredWriteTx) error {
+		if !tx.Exists(topicEventsPath(topic)) {
+			return errTopicNotFound
+		}
+
+		latest, found, err := latestSchema(tx, topic, reg.Type)
+		if err != nil {
+			return err
+		}
+
+		if found && bytes.Equal(latest.Schema, reg.Schema) && (reg.Compatibility == "" || reg.Compatibility == latest.Compatibility) {
+			// registering the latest version again is a no-op
+			sv = latest
+			return nil
+		}
+
+		sv = schemaVersion{
+			Topic:         topic,
+			Type:          reg.Type,
+			Version:       1,
+			Compatibility: reg.Compatibility,
+			Created:       time.Now().UTC(),
+			Schema:        reg.Schema,
+		}
+
+		if found {
+			sv.Version = latest.Version + 1
+			if sv.Compatibility == "" {
+				sv.Compatibility = latest.Compatibility
+			}
+			problems, err := schemaIncompatibilities(sv.Compatibility, latest.Schema, sv.Schema)
+			if err != nil {
+				return err
+			}
+			if len(problems) > 0 {
+				return fmt.Errorf("%w: not %s compatible with version %d: %s", errIncompatibleSchema, sv.Compatibility, latest.Version, strings.Join(problems, "; "))
+			}
+		}
+
+		if sv.Compatibility == "" {
+			sv.Compatibility = compatibilityNone
+		}
+
+		for _, p := range []dbpath.Path{schemasPath.Append(topic), schemaSubjectPath(topic, reg.Type)} {
+			if !tx.Exists(p) {
+				tx.CreateMap(p)
+			}
+		}
+
+		d, err := json.Marshal(sv)
+		if err != nil {
+			return err
+		}
+
+		tx.Put(schemaSubjectPath(topic, reg.Type).Append(schemaVersionKey(sv.Version)), d)
+		registered = true
+		return nil
+	})
+
+	if errors.Is(err, errTopicNotFound) {
+		http.Error(w, fmt.Errorf("topic %s: %w", topic, err).Error(), http.StatusNotFound)
+		return
+	}
+
+	if errors.Is(err, errIncompatibleSchema) {
+		log.Info("rejected incompatible schema", "topic", topic, "type", reg.Type, "error", err.Error())
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	if err != nil {
+		log.Error(err, "could not register schema", "topic", topic)
+		http.Error(w, fmt.Errorf("could not register schema: %w", err).Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	if registered {
+		s.forgetSchemas(topic, reg.Type, false)
+		log.Info("schema registered", "topic", topic, "type", reg.Type, "version", sv.Version)
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(sv)
+}
+
+func (s *Server) listSchemas(w http.ResponseWriter, r *http.Request) {
+	log := s.log.WithValues("method", r.Method, "path", r.URL.Path)
+
+	topic, err := requestTopic(r)
+	if err != nil {
+		log.Error(err, "invalid topic")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// an empty type selects the schemas of the whole topic
+	q := r.URL.Query()
+
+	var versions []schemaVersion
+	err = bolted.SugaredRead(s.db, func(tx bolted.SugaredReadTx) error {
+		if !tx.Exists(topicEventsPath(topic)) {
+			return errTopicNotFound
+		}
+		vs, err := schemaVersions(tx, topic, q.Get("type"), q.Has("type"))
+		versions = vs
+		return err
+	})
+
+	if errors.Is(err, errTopicNotFound) {
+		http.Error(w, fmt.Errorf("topic %s: %w", topic, err).Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		log.Error(err, "could not list schemas", "topic", topic)
+		http.Error(w, fmt.Errorf("could not list schemas: %w", err).Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(versions)
+}
+
+// deleteSchema deletes all versions of the schema selected by the type query parameter,
+// the schema of the whole topic if it is not set.
+func (s *Server) deleteSchema(w http.ResponseWriter, r *http.Request) {
+	log := s.log.WithValues("method", r.Method, "path", r.URL.Path)
+
+	topic, err := requestTopic(r)
+	if err != nil {
+		log.Error(err, "invalid topic")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	eventType := r.URL.Query().Get("type")
+
+	err = bolted.SugaredWrite(s.db, func(tx bolted.SugaredWriteTx) error {
+		p := schemaSubjectPath(topic, eventType)
+		if !tx.Exists(p) {
+			return errSchemaNotFound
+		}
+		tx.Delete(p)
+		if tx.Size(schemasPath.Append(topic)) == 0 {
+			tx.Delete(schemasPath.Append(topic))
+		}
+		return nil
+	})
+
+	if errors.Is(err, errSchemaNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		log.Error(err, "could not delete schema", "topic", topic)
+		http.Error(w, fmt.Errorf("could not delete schema: %w", err).Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.forgetSchemas(topic, eventType, false)
+
+	log.Info("schema deleted", "topic", topic, "type", eventType)
+	w.WriteHeader(http.StatusOK)
+}
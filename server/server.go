@@ -2,34 +2,85 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/http/httputil"
+	"net/url"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/draganm/bolted"
 	"github.com/draganm/bolted/dbpath"
 	"github.com/go-logr/logr"
-	"github.com/gofrs/uuid"
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 type Server struct {
-	db  bolted.Database
-	log logr.Logger
+	db                bolted.Database
+	log               logr.Logger
+	idempotencyWindow time.Duration
+	maxEvents         int64
+	maxBytes          int64
+	retentionOnWrite  bool
+	// consumerRetentionMaxAge enables consumer-aware retention when non-zero.
+	consumerRetentionMaxAge time.Duration
+	// compaction is the default of topics without a compaction setting.
+	compaction bool
+	// authenticators verify the credentials of requests, authentication is disabled if there are none.
+	authenticators []Authenticator
+	// leaderURL is set on followers replicating the leader.
+	leaderURL       *url.URL
+	forwardWrites   bool
+	leaderTLSConfig *tls.Config
+	leaderProxy     *httputil.ReverseProxy
+	// followRefreshInterval is how often a follower refreshes the state of the leader besides events.
+	followRefreshInterval time.Duration
+	// compiledSchemas caches the compiled latest versions of JSON schemas by schemaCacheKey.
+	compiledSchemas *sync.Map
 	http.Handler
 }
 
 var eventsPath = dbpath.ToPath("events")
 
-func New(log logr.Logger, db bolted.Database) (*Server, error) {
+func New(log logr.Logger, db bolted.Database, opts ...Option) (*Server, error) {
 	err := bolted.SugaredWrite(db, func(tx bolted.SugaredWriteTx) error {
 		if !tx.Exists(eventsPath) {
 			tx.CreateMap(eventsPath)
 
 		}
+		if !tx.Exists(topicsPath) {
+			tx.CreateMap(topicsPath)
+		}
+		if !tx.Exists(consumerGroupsPath) {
+			tx.CreateMap(consumerGroupsPath)
+		}
+		if !tx.Exists(idempotencyKeysPath) {
+			tx.CreateMap(idempotencyKeysPath)
+		}
+		if !tx.Exists(auditPath) {
+			tx.CreateMap(auditPath)
+		}
+		if !tx.Exists(replicationCursorsPath) {
+			tx.CreateMap(replicationCursorsPath)
+		}
+		if !tx.Exists(replicationAuditCursorsPath) {
+			tx.CreateMap(replicationAuditCursorsPath)
+		}
+		if !tx.Exists(pendingErasuresPath) {
+			tx.CreateMap(pendingErasuresPath)
+		}
+		if !tx.Exists(topicConfigsPath) {
+			tx.CreateMap(topicConfigsPath)
+		}
+		if !tx.Exists(schemasPath) {
+			tx.CreateMap(schemasPath)
+		}
+		initTopicSizes(tx)
 		return nil
 	})
 
@@ -37,140 +88,228 @@ func New(log logr.Logger, db bolted.Database) (*Server, error) {
 		return nil, fmt.Errorf("could not initialize db: %w", err)
 	}
 
+	s := &Server{
+		db:                db,
+		log:               log,
+		idempotencyWindow: DefaultIdempotencyWindow,
+		compiledSchemas:   &sync.Map{},
+
+		followRefreshInterval: defaultFollowRefreshInterval,
+	}
+
+	for _, o := range opts {
+		o(s)
+	}
+
+	if s.leaderURL != nil {
+		s.leaderProxy = httputil.NewSingleHostReverseProxy(s.leaderURL)
+		if s.leaderTLSConfig != nil {
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			transport.TLSClientConfig = s.leaderTLSConfig
+			s.leaderProxy.Transport = transport
+		}
+	}
+
 	r := mux.NewRouter()
 
-	r.Methods("POST").Path("/events").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	r.Methods("POST").Path("/events").HandlerFunc(s.requireScope(ScopePublish, s.writable(s.publishEvents)))
+	// scopes of WebSocket requests are checked per message
+	r.Methods("GET").Path("/events").MatcherFunc(isWebSocketUpgrade).HandlerFunc(s.requireScope("", s.webSocket))
+	r.Methods("GET").Path("/events").MatcherFunc(acceptsEventStream).HandlerFunc(s.requireScope(ScopeRead, s.streamEvents))
+	r.Methods("GET").Path("/events").HandlerFunc(s.requireScope(ScopeRead, s.pollEvents))
+	r.Methods("POST").Path("/events/lookup").HandlerFunc(s.requireScope(ScopeRead, s.lookupEvents))
+	r.Methods("GET").Path("/events/{id}").HandlerFunc(s.requireScope(ScopeRead, s.getEvent))
+	r.Methods("POST").Path("/events/delete").HandlerFunc(s.requireScope(ScopeAdmin, s.writable(s.deleteEvents)))
+	r.Methods("POST").Path("/events/redact").HandlerFunc(s.requireScope(ScopeAdmin, s.writable(s.redactEvents)))
+	r.Methods("GET").Path("/audit").HandlerFunc(s.requireScope(ScopeAdmin, s.listAuditRecords))
+	r.Methods("GET").Path("/schemas").HandlerFunc(s.requireScope(ScopeRead, s.listSchemas))
+	r.Methods("POST").Path("/schemas").HandlerFunc(s.requireScope(ScopeAdmin, s.writable(s.registerSchema)))
+	r.Methods("DELETE").Path("/schemas").HandlerFunc(s.requireScope(ScopeAdmin, s.writable(s.deleteSchema)))
+
+	r.Methods("GET").Path("/topics").HandlerFunc(s.requireScope("", s.listTopics))
+	r.Methods("GET").Path("/stats").HandlerFunc(s.requireScope("", s.stats))
+	r.Methods("GET").Path("/topics/{topic}").HandlerFunc(s.requireScope(ScopeRead, s.getTopic))
+	r.Methods("PUT").Path("/topics/{topic}").HandlerFunc(s.requireScope(ScopeAdmin, s.writable(s.createTopic)))
+	r.Methods("DELETE").Path("/topics/{topic}").HandlerFunc(s.requireScope(ScopeAdmin, s.writable(s.deleteTopic)))
+	r.Methods("POST").Path("/topics/{topic}/events").HandlerFunc(s.requireScope(ScopePublish, s.writable(s.publishEvents)))
+	r.Methods("GET").Path("/topics/{topic}/events").MatcherFunc(isWebSocketUpgrade).HandlerFunc(s.requireScope("", s.webSocket))
+	r.Methods("GET").Path("/topics/{topic}/events").MatcherFunc(acceptsEventStream).HandlerFunc(s.requireScope(ScopeRead, s.streamEvents))
+	r.Methods("GET").Path("/topics/{topic}/events").HandlerFunc(s.requireScope(ScopeRead, s.pollEvents))
+	r.Methods("POST").Path("/topics/{topic}/events/lookup").HandlerFunc(s.requireScope(ScopeRead, s.lookupEvents))
+	r.Methods("GET").Path("/topics/{topic}/events/{id}").HandlerFunc(s.requireScope(ScopeRead, s.getEvent))
+	r.Methods("POST").Path("/topics/{topic}/events/delete").HandlerFunc(s.requireScope(ScopeAdmin, s.writable(s.deleteEvents)))
+	r.Methods("POST").Path("/topics/{topic}/events/redact").HandlerFunc(s.requireScope(ScopeAdmin, s.writable(s.redactEvents)))
+	r.Methods("GET").Path("/topics/{topic}/audit").HandlerFunc(s.requireScope(ScopeAdmin, s.listAuditRecords))
+	r.Methods("GET").Path("/topics/{topic}/schemas").HandlerFunc(s.requireScope(ScopeRead, s.listSchemas))
+	r.Methods("POST").Path("/topics/{topic}/schemas").HandlerFunc(s.requireScope(ScopeAdmin, s.writable(s.registerSchema)))
+	r.Methods("DELETE").Path("/topics/{topic}/schemas").HandlerFunc(s.requireScope(ScopeAdmin, s.writable(s.deleteSchema)))
+
+	r.Methods("GET").Path("/groups").HandlerFunc(s.requireScope(ScopeRead, s.listGroupOffsets))
+	r.Methods("GET").Path("/groups/{group}").HandlerFunc(s.requireScope(ScopeRead, s.getGroupOffset))
+	r.Methods("POST").Path("/groups/{group}/commit").HandlerFunc(s.requireScope(ScopeRead, s.writable(s.commitGroupOffset)))
+	r.Methods("GET").Path("/topics/{topic}/groups").HandlerFunc(s.requireScope(ScopeRead, s.listGroupOffsets))
+	r.Methods("GET").Path("/topics/{topic}/groups/{group}").HandlerFunc(s.requireScope(ScopeRead, s.getGroupOffset))
+	r.Methods("POST").Path("/topics/{topic}/groups/{group}/commit").HandlerFunc(s.requireScope(ScopeRead, s.writable(s.commitGroupOffset)))
 
-		log := log.WithValues("method", r.Method, "path", r.URL.Path)
-		events := []json.RawMessage{}
+	prometheus.Register(newStatsCollector(db, log))
 
-		err := json.NewDecoder(r.Body).Decode(&events)
+	s.Handler = r
 
-		if err != nil {
-			log.Error(err, "could not decode request")
-			http.Error(w, fmt.Errorf("could not decode request: %w", err).Error(), http.StatusBadRequest)
-			return
-		}
+	return s, nil
+}
 
-		uuids := make([]string, len(events))
-		for i := range events {
-			id, err := uuid.NewV6()
-			if err != nil {
-				log.Error(err, "could not generate UUID")
-				http.Error(w, fmt.Errorf("could not generate UUID: %w", err).Error(), http.StatusInternalServerError)
-				return
-			}
-			uuids[i] = id.String()
-		}
+const maxLimit = 1000
 
-		err = bolted.SugaredWrite(db, func(tx bolted.SugaredWriteTx) error {
-			for i, ev := range events {
-				tx.Put(eventsPath.Append(uuids[i]), ev)
-			}
-			return nil
-		})
+// requestLimit returns the limit query parameter, 100 if it is not set.
+func requestLimit(q url.Values) (int, error) {
+	limitString := q.Get("limit")
+	if limitString == "" {
+		return 100, nil
+	}
+	limit64, err := strconv.ParseInt(limitString, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse limit: %w", err)
+	}
+	if limit64 > maxLimit {
+		return 0, fmt.Errorf("requested limit %d is larger than allowed %d", limit64, maxLimit)
+	}
+	return int(limit64), nil
+}
 
-		if err != nil {
-			log.Error(err, "could not store events")
-			http.Error(w, fmt.Errorf("could not store events: %w", err).Error(), http.StatusInternalServerError)
-			return
-		}
+// cursorHeader holds the ID of the last event examined by a poll request.
+const cursorHeader = "Event-Buffer-Cursor"
 
-		w.WriteHeader(http.StatusOK)
+// pollTimeout is the maximal duration of a long poll.
+const pollTimeout = time.Second * 20
 
-	})
-	const maxLimit = 1000
-
-	r.Methods("GET").Path("/events").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log := log.WithValues("method", r.Method, "path", r.URL.Path)
-
-		q := r.URL.Query()
-
-		after := q.Get("after")
-
-		limit := 100
-		limitString := q.Get("limit")
-		if limitString != "" {
-			limit64, err := strconv.ParseInt(limitString, 10, 64)
-			if err != nil {
-				log.Error(err, "could not parse limit", "limit", limitString)
-				http.Error(w, fmt.Errorf("could not parse limit: %w", err).Error(), http.StatusBadRequest)
-				return
-			}
-			if limit64 > maxLimit {
-				log.Error(err, "too large limit requested", "limit", limit64)
-				http.Error(w, fmt.Errorf("requested limit %d is larger than allowed %d", limit64, maxLimit).Error(), http.StatusBadRequest)
-				return
-			}
-			limit = int(limit64)
-		}
+func (s *Server) pollEvents(w http.ResponseWriter, r *http.Request) {
+	log := s.log.WithValues("method", r.Method, "path", r.URL.Path)
 
-		changes, done := db.Observe(eventsPath.ToMatcher().AppendAnyElementMatcher())
-		defer done()
-		events := []event{}
+	topic, err := requestTopic(r)
+	if err != nil {
+		log.Error(err, "invalid topic")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-		timeout := time.Second * 20
+	q := r.URL.Query()
 
-		ctx, done := context.WithTimeout(r.Context(), timeout)
-		defer done()
+	after := q.Get("after")
 
-		for ctx.Err() == nil {
-
-			select {
-			case <-changes:
-			case <-ctx.Done():
-				continue
-			}
-
-			err := bolted.SugaredRead(db, func(tx bolted.SugaredReadTx) error {
-				it := tx.Iterator(eventsPath)
-				if after != "" {
-					it.Seek(after)
-					if !it.IsDone() {
-						if it.GetKey() == after {
-							it.Next()
-						}
-					}
-				}
-				for ; !it.IsDone() && len(events) < limit; it.Next() {
-					events = append(events, event{it.GetKey(), it.GetValue()})
-				}
-				return nil
-			})
-
-			if err != nil {
-				log.Error(err, "could not read events: %w", err)
-				http.Error(w, fmt.Errorf("could not read events: %w", err).Error(), http.StatusInternalServerError)
-				return
-			}
-
-			if len(events) > 0 {
-				break
-			}
-		}
+	limit, err := requestLimit(q)
+	if err != nil {
+		log.Error(err, "invalid limit", "limit", q.Get("limit"))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	since, until, err := parseTimeRange(q)
+	if err != nil {
+		log.Error(err, "invalid time range")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !since.IsZero() && (after != "" || q.Get("group") != "") {
+		http.Error(w, fmt.Errorf("%w: since is mutually exclusive with after and group", errInvalidRequest).Error(), http.StatusBadRequest)
+		return
+	}
+
+	after, err = s.startPosition(topic, after, q.Get("group"))
+	if errors.Is(err, errInvalidRequest) {
+		log.Error(err, "invalid start position")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-		if ctx.Err() == context.DeadlineExceeded {
-			log.Error(err, "request timed out")
-			http.Error(w, fmt.Errorf("request timed out: %w", err).Error(), http.StatusRequestTimeout)
-			return
+	if err != nil {
+		log.Error(err, "could not determine start position")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	filter, err := parseFilter(q["filter"])
+	if err != nil {
+		log.Error(err, "invalid filter")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !since.IsZero() {
+		after = timeID(since)
+	}
+
+	before := ""
+	wait := q.Get("wait") != "false"
+	timeout := pollTimeout
+
+	if !until.IsZero() {
+		before = timeID(until)
+		// events published after until are not in the range, there is no point in waiting for them
+		timeout = time.Until(until)
+		if timeout > pollTimeout {
+			timeout = pollTimeout
 		}
+		if timeout <= 0 {
+			wait = false
+		}
+	}
 
-		if ctx.Err() != nil {
-			log.Error(err, "request context cancelled")
-			http.Error(w, fmt.Errorf("request context cancelled: %w", err).Error(), http.StatusInternalServerError)
-			return
+	var events []event
+	var cursor string
+
+	if wait {
+		ctx, done := context.WithTimeout(r.Context(), timeout)
+		defer done()
+		events, cursor, err = s.waitForEvents(ctx, topic, after, before, limit, filter)
+		if err == context.DeadlineExceeded && !until.IsZero() && !time.Now().Before(until) {
+			// the range ended while waiting
+			events, err = []event{}, nil
 		}
+	} else {
+		// return the currently stored events, even if there are none
+		err = bolted.SugaredRead(s.db, func(tx bolted.SugaredReadTx) (err error) {
+			events, cursor, err = scanEvents(tx, topicEventsPath(topic), after, before, limit, filter)
+			return err
+		})
+	}
 
-		w.Header().Set("content-type", "application/json")
-		json.NewEncoder(w).Encode(events)
+	if cursor != "" && (err == nil || err == context.DeadlineExceeded) {
+		// clients polling with a filter continue from the cursor to skip non-matching events
+		w.Header().Set(cursorHeader, cursor)
+	}
 
-	})
+	if errors.Is(err, errTopicNotFound) {
+		log.Error(err, "could not read events", "topic", topic)
+		http.Error(w, fmt.Errorf("topic %s: %w", topic, err).Error(), http.StatusNotFound)
+		return
+	}
 
-	prometheus.Register(newStatsCollector(db, log))
+	if err == context.DeadlineExceeded {
+		log.Error(err, "request timed out")
+		http.Error(w, fmt.Errorf("request timed out: %w", err).Error(), http.StatusRequestTimeout)
+		return
+	}
+
+	if err == context.Canceled {
+		log.Error(err, "request context cancelled")
+		http.Error(w, fmt.Errorf("request context cancelled: %w", err).Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err != nil {
+		log.Error(err, "could not read events")
+		http.Error(w, fmt.Errorf("could not read events: %w", err).Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if acceptsEnvelopes(r) {
+		w.Header().Set("content-type", envelopeMediaType)
+		json.NewEncoder(w).Encode(toEnvelopes(events))
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(events)
 
-	return &Server{
-		Handler: r,
-		db:      db,
-		log:     log,
-	}, nil
 }
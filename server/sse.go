@@ -0,0 +1,165 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/draganm/bolted"
+	"github.com/gorilla/mux"
+)
+
+const sseKeepAliveInterval = 15 * time.Second
+
+// acceptsEventStream matches requests of EventSource clients.
+func acceptsEventStream(r *http.Request, rm *mux.RouteMatch) bool {
+	return strings.Contains(r.Header.Get("accept"), "text/event-stream")
+}
+
+// streamEvents keeps the connection open and pushes events as Server-Sent Events.
+// The event ID is sent as the SSE id, so reconnecting clients resume
+// after the last received event using the Last-Event-ID header.
+// The data of each event is its payload, or its envelope if the format=envelope parameter is set.
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	log := s.log.WithValues("method", r.Method, "path", r.URL.Path)
+
+	topic, err := requestTopic(r)
+	if err != nil {
+		log.Error(err, "invalid topic")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	q := r.URL.Query()
+
+	envelopes := q.Get("format") == "envelope"
+
+	after := q.Get("after")
+	lastEventID := r.Header.Get("last-event-id")
+	if lastEventID != "" {
+		after = lastEventID
+	}
+
+	group := q.Get("group")
+	if lastEventID != "" {
+		// resuming a stream takes precedence over the committed offset
+		group = ""
+	}
+
+	after, err = s.startPosition(topic, after, group)
+	if errors.Is(err, errInvalidRequest) {
+		log.Error(err, "invalid start position")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		log.Error(err, "could not determine start position")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	topicPath := topicEventsPath(topic)
+
+	err = bolted.SugaredRead(s.db, func(tx bolted.SugaredReadTx) error {
+		if !tx.Exists(topicPath) {
+			return errTopicNotFound
+		}
+		return nil
+	})
+
+	if errors.Is(err, errTopicNotFound) {
+		http.Error(w, fmt.Errorf("topic %s: %w", topic, err).Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		log.Error(err, "could not read topic")
+		http.Error(w, fmt.Errorf("could not read topic: %w", err).Error(), http.StatusInternalServerError)
+		return
+	}
+
+	changes, done := s.db.Observe(topicPath.ToMatcher().AppendAnyElementMatcher())
+	defer done()
+
+	w.Header().Set("content-type", "text/event-stream")
+	w.Header().Set("cache-control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	ctx := r.Context()
+	buf := &bytes.Buffer{}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepAlive.C:
+			_, err = fmt.Fprint(w, ": keep-alive\n\n")
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+			continue
+		case <-changes:
+		}
+
+		for {
+			var events []event
+			err = bolted.SugaredRead(s.db, func(tx bolted.SugaredReadTx) (err error) {
+				events, err = readEvents(tx, topicPath, after, maxLimit)
+				return err
+			})
+
+			if errors.Is(err, errTopicNotFound) {
+				log.Info("topic deleted, closing stream", "topic", topic)
+				return
+			}
+
+			if err != nil {
+				log.Error(err, "could not read events")
+				return
+			}
+
+			for _, e := range events {
+				buf.Reset()
+				if envelopes {
+					var d []byte
+					d, err = json.Marshal(eventEnvelope(e))
+					buf.Write(d)
+				} else {
+					err = json.Compact(buf, e.payload)
+				}
+				if err != nil {
+					log.Error(err, "could not encode event", "id", e.id)
+					return
+				}
+				_, err = fmt.Fprintf(w, "id: %s\ndata: %s\n\n", e.id, buf.Bytes())
+				if err != nil {
+					return
+				}
+				after = e.id
+			}
+
+			flusher.Flush()
+
+			if len(events) < maxLimit {
+				break
+			}
+		}
+	}
+
+}
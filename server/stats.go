@@ -0,0 +1,113 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/draganm/bolted"
+)
+
+type topicStats struct {
+	Topic  string       `json:"topic"`
+	Events int          `json:"events"`
+	Bytes  int64        `json:"bytes"`
+	Oldest *eventStats  `json:"oldest,omitempty"`
+	Newest *eventStats  `json:"newest,omitempty"`
+	Groups []groupStats `json:"groups"`
+}
+
+type eventStats struct {
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+}
+
+type groupStats struct {
+	Group string `json:"group"`
+	ID    string `json:"id"`
+	// Lag is the number of events stored after the committed event.
+	Lag int `json:"lag"`
+}
+
+func newEventStats(id string) (*eventStats, error) {
+	t, err := eventTime(id)
+	if err != nil {
+		return nil, err
+	}
+	return &eventStats{ID: id, Time: t.UTC()}, nil
+}
+
+// readTopicStats returns the statistics of the topic, iterating over the events after the committed offsets to count the lag.
+func readTopicStats(tx bolted.SugaredReadTx, topic string) (topicStats, error) {
+	topicPath := topicEventsPath(topic)
+
+	ts := topicStats{
+		Topic:  topic,
+		Events: int(tx.Size(topicPath)),
+		Bytes:  topicSize(tx, topic),
+		Groups: []groupStats{},
+	}
+
+	it := tx.Iterator(topicPath)
+	if !it.IsDone() {
+		oldest, err := newEventStats(it.GetKey())
+		if err != nil {
+			return ts, err
+		}
+		ts.Oldest = oldest
+
+		it.Last()
+		newest, err := newEventStats(it.GetKey())
+		if err != nil {
+			return ts, err
+		}
+		ts.Newest = newest
+	}
+
+	for _, o := range groupOffsets(tx, topic) {
+		lag := 0
+		it := tx.Iterator(topicPath)
+		if o.ID != "" {
+			it.Seek(o.ID)
+			if !it.IsDone() && it.GetKey() == o.ID {
+				it.Next()
+			}
+		}
+		for ; !it.IsDone(); it.Next() {
+			lag++
+		}
+		ts.Groups = append(ts.Groups, groupStats{Group: o.Group, ID: o.ID, Lag: lag})
+	}
+
+	return ts, nil
+}
+
+func (s *Server) stats(w http.ResponseWriter, r *http.Request) {
+	log := s.log.WithValues("method", r.Method, "path", r.URL.Path)
+
+	stats := []topicStats{}
+	err := bolted.SugaredRead(s.db, func(tx bolted.SugaredReadTx) error {
+		for _, topic := range topicNames(tx) {
+			// principals only see the topics they may read
+			if !s.authorized(r.Context(), ScopeRead, topic) {
+				continue
+			}
+			ts, err := readTopicStats(tx, topic)
+			if err != nil {
+				return err
+			}
+			stats = append(stats, ts)
+		}
+		return nil
+	})
+
+	if err != nil {
+		log.Error(err, "could not read stats")
+		http.Error(w, fmt.Errorf("could not read stats: %w", err).Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
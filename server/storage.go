@@ -0,0 +1,84 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/draganm/bolted"
+	"github.com/draganm/bolted/dbpath"
+)
+
+// topicSizesPath maps topic names to the number of bytes occupied by their events.
+// All writes and deletions of events go through putEvents and deleteEvents,
+// which keep the sizes up to date.
+var topicSizesPath = dbpath.ToPath("topic-sizes")
+
+// eventSize is the number of bytes an event occupies in the retention accounting.
+func eventSize(id string, value []byte) int64 {
+	return int64(len(id) + len(value))
+}
+
+// topicSize returns the number of bytes occupied by the events of the topic.
+func topicSize(tx bolted.SugaredReadTx, topic string) int64 {
+	p := topicSizesPath.Append(topic)
+	if !tx.Exists(p) {
+		return 0
+	}
+	size, err := strconv.ParseInt(string(tx.Get(p)), 10, 64)
+	if err != nil {
+		panic(fmt.Errorf("could not parse size of topic %s: %w", topic, err))
+	}
+	return size
+}
+
+func setTopicSize(tx bolted.SugaredWriteTx, topic string, size int64) {
+	tx.Put(topicSizesPath.Append(topic), []byte(strconv.FormatInt(size, 10)))
+}
+
+// putEvents stores the encoded events under the given IDs.
+// Existing events with the same IDs are replaced.
+func putEvents(tx bolted.SugaredWriteTx, topic string, ids []string, values [][]byte) {
+	topicPath := topicEventsPath(topic)
+	size := topicSize(tx, topic)
+	for i, id := range ids {
+		p := topicPath.Append(id)
+		if tx.Exists(p) {
+			size -= eventSize(id, tx.Get(p))
+		}
+		tx.Put(p, values[i])
+		size += eventSize(id, values[i])
+	}
+	setTopicSize(tx, topic, size)
+}
+
+// deleteEvents deletes the events with the given IDs, ignoring IDs that do not exist.
+func deleteEvents(tx bolted.SugaredWriteTx, topic string, ids []string) {
+	topicPath := topicEventsPath(topic)
+	size := topicSize(tx, topic)
+	for _, id := range ids {
+		p := topicPath.Append(id)
+		if !tx.Exists(p) {
+			continue
+		}
+		size -= eventSize(id, tx.Get(p))
+		tx.Delete(p)
+	}
+	setTopicSize(tx, topic, size)
+}
+
+// initTopicSizes computes sizes of topics stored before sizes were tracked.
+func initTopicSizes(tx bolted.SugaredWriteTx) {
+	if !tx.Exists(topicSizesPath) {
+		tx.CreateMap(topicSizesPath)
+	}
+	for _, topic := range topicNames(tx) {
+		if tx.Exists(topicSizesPath.Append(topic)) {
+			continue
+		}
+		var size int64
+		for it := tx.Iterator(topicEventsPath(topic)); !it.IsDone(); it.Next() {
+			size += eventSize(it.GetKey(), it.GetValue())
+		}
+		setTopicSize(tx, topic, size)
+	}
+}
@@ -3,39 +3,79 @@ package testrig
 import (
 	"context"
 	"fmt"
+	"io"
+	"net"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 
+	"github.com/draganm/bolted"
 	"github.com/draganm/bolted/embedded"
 	"github.com/draganm/event-buffer/server"
 	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
 )
 
-func StartServer(ctx context.Context, log logr.Logger) (string, error) {
+type ServerRig struct {
+	URL      string
+	GRPCAddr string
+	Server   *server.Server
+	DB       bolted.Database
+}
+
+func StartServer(ctx context.Context, log logr.Logger, opts ...server.Option) (*ServerRig, error) {
+	return startServer(ctx, log, func(db bolted.Database) error { return nil }, opts...)
+}
+
+// StartServerFromBackup starts a server with the state restored from the backup.
+func StartServerFromBackup(ctx context.Context, log logr.Logger, backup io.Reader, opts ...server.Option) (*ServerRig, error) {
+	return startServer(ctx, log, func(db bolted.Database) error {
+		_, err := server.Restore(db, backup)
+		return err
+	}, opts...)
+}
+
+func startServer(ctx context.Context, log logr.Logger, initDB func(db bolted.Database) error, opts ...server.Option) (*ServerRig, error) {
 	td, err := os.MkdirTemp("", "")
 	if err != nil {
-		return "", fmt.Errorf("could not create temp dir: %w", err)
+		return nil, fmt.Errorf("could not create temp dir: %w", err)
 	}
 
 	db, err := embedded.Open(filepath.Join(td, "db"), 0700, embedded.Options{})
 	if err != nil {
-		return "", fmt.Errorf("could not open db: %w", err)
+		return nil, fmt.Errorf("could not open db: %w", err)
 	}
 
-	server, err := server.New(log, db)
+	err = initDB(db)
+	if err != nil {
+		db.Close()
+		os.RemoveAll(td)
+		return nil, err
+	}
+
+	srv, err := server.New(log, db, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("could not start server: %w", err)
+	}
+
+	hs := httptest.NewServer(srv)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
-		return "", fmt.Errorf("could not start server: %w", err)
+		return nil, fmt.Errorf("could not listen for grpc: %w", err)
 	}
 
-	hs := httptest.NewServer(server)
+	gs := grpc.NewServer()
+	srv.RegisterGRPC(gs)
+	go gs.Serve(l)
 
 	go func() {
 		<-ctx.Done()
+		gs.Stop()
 		hs.Close()
 		db.Close()
 		os.RemoveAll(td)
 	}()
 
-	return hs.URL, nil
+	return &ServerRig{URL: hs.URL, GRPCAddr: l.Addr().String(), Server: srv, DB: db}, nil
 }
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// NewTLSConfig returns a TLS configuration serving the certificate in certFile and keyFile.
+// If clientCAFile is set, clients have to present a certificate signed by one of its CAs.
+// The files are reloaded when they change, so certificates can be rotated without a restart.
+func NewTLSConfig(log logr.Logger, certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert := &reloadingFile[*tls.Certificate]{
+		files: []string{certFile, keyFile},
+		load: func() (*tls.Certificate, error) {
+			c, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return nil, fmt.Errorf("could not load certificate: %w", err)
+			}
+			return &c, nil
+		},
+		log: log.WithValues("certFile", certFile),
+	}
+
+	_, err := cert.get()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// configurations returned by GetConfigForClient replace the ones of http.Server and gRPC,
+		// so they have to offer HTTP/2 themselves
+		NextProtos: []string{"h2", "http/1.1"},
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return cert.get()
+		},
+	}
+
+	if clientCAFile == "" {
+		return cfg, nil
+	}
+
+	clientCAs := &reloadingFile[*x509.CertPool]{
+		files: []string{clientCAFile},
+		load: func() (*x509.CertPool, error) {
+			return loadCertPool(clientCAFile)
+		},
+		log: log.WithValues("clientCAFile", clientCAFile),
+	}
+
+	_, err = clientCAs.get()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		pool, err := clientCAs.get()
+		if err != nil {
+			return nil, err
+		}
+		c := cfg.Clone()
+		c.GetConfigForClient = nil
+		c.ClientAuth = tls.RequireAndVerifyClientCert
+		c.ClientCAs = pool
+		return c, nil
+	}
+
+	return cfg, nil
+}
+
+func loadCertPool(fileName string) (*x509.CertPool, error) {
+	d, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("could not read CA certificates: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(d) {
+		return nil, fmt.Errorf("no CA certificates found in %s", fileName)
+	}
+	return pool, nil
+}
+
+// reloadingFile holds a value loaded from files, loading it again when the modification time of a file changes.
+// If loading fails, the previously loaded value is used.
+type reloadingFile[T any] struct {
+	files []string
+	load  func() (T, error)
+	log   logr.Logger
+
+	mu       sync.Mutex
+	value    T
+	loaded   bool
+	modTimes []time.Time
+}
+
+func (r *reloadingFile[T]) get() (T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	modTimes := make([]time.Time, len(r.files))
+	for i, f := range r.files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			if r.loaded {
+				// keep the current value while the files are being replaced
+				return r.value, nil
+			}
+			return r.value, fmt.Errorf("could not stat %s: %w", f, err)
+		}
+		modTimes[i] = fi.ModTime()
+	}
+
+	if r.loaded && sameTimes(modTimes, r.modTimes) {
+		return r.value, nil
+	}
+
+	v, err := r.load()
+	if err != nil {
+		if r.loaded {
+			r.log.Error(err, "could not reload, keeping the previous version")
+			// don't retry until the files change again
+			r.modTimes = modTimes
+			return r.value, nil
+		}
+		return r.value, err
+	}
+
+	if r.loaded {
+		r.log.Info("reloaded")
+	}
+
+	r.value = v
+	r.loaded = true
+	r.modTimes = modTimes
+
+	return v, nil
+}
+
+func sameTimes(a, b []time.Time) bool {
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,272 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+
+	"github.com/draganm/bolted"
+	"github.com/draganm/bolted/dbpath"
+	"github.com/gorilla/mux"
+)
+
+// DefaultTopic is the topic served by the /events endpoints.
+// Its events are stored under eventsPath so that state files created
+// before topics were introduced keep working.
+const DefaultTopic = "default"
+
+var topicsPath = dbpath.ToPath("topics")
+
+// topicConfigsPath maps topic names to their JSON encoded topicConfig.
+var topicConfigsPath = dbpath.ToPath("topic-configs")
+
+var topicNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,200}$`)
+
+var errTopicNotFound = errors.New("topic not found")
+
+func validateTopicName(name string) error {
+	if !topicNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid topic name %q: must match %s", name, topicNameRegexp.String())
+	}
+	return nil
+}
+
+func topicEventsPath(topic string) dbpath.Path {
+	if topic == DefaultTopic {
+		return eventsPath
+	}
+	return topicsPath.Append(topic)
+}
+
+// requestTopic returns the topic addressed by the request,
+// falling back to the default topic for the /events endpoints.
+func requestTopic(r *http.Request) (string, error) {
+	topic, found := mux.Vars(r)["topic"]
+	if !found {
+		return DefaultTopic, nil
+	}
+	err := validateTopicName(topic)
+	if err != nil {
+		return "", err
+	}
+	return topic, nil
+}
+
+func topicNames(tx bolted.SugaredReadTx) []string {
+	names := []string{DefaultTopic}
+	for it := tx.Iterator(topicsPath); !it.IsDone(); it.Next() {
+		names = append(names, it.GetKey())
+	}
+	return names
+}
+
+// createTopic creates the topic if it doesn't exist and returns true if it was created.
+func createTopic(tx bolted.SugaredWriteTx, topic string) bool {
+	p := topicEventsPath(topic)
+	if tx.Exists(p) {
+		return false
+	}
+	tx.CreateMap(p)
+	setTopicSize(tx, topic, 0)
+	return true
+}
+
+// topicConfig is the configuration of a topic.
+// Unset fields fall back to the options of the server.
+type topicConfig struct {
+	// Compaction enables key-based compaction of the topic, see compaction.
+	Compaction *bool `json:"compaction,omitempty"`
+}
+
+// getTopicConfig returns the configuration of the topic, or an empty configuration if it has none.
+func getTopicConfig(tx bolted.SugaredReadTx, topic string) (topicConfig, error) {
+	cfg := topicConfig{}
+	p := topicConfigsPath.Append(topic)
+	if !tx.Exists(p) {
+		return cfg, nil
+	}
+	err := json.Unmarshal(tx.Get(p), &cfg)
+	if err != nil {
+		return cfg, fmt.Errorf("could not decode configuration of topic %s: %w", topic, err)
+	}
+	return cfg, nil
+}
+
+func putTopicConfig(tx bolted.SugaredWriteTx, topic string, cfg topicConfig) error {
+	d, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	tx.Put(topicConfigsPath.Append(topic), d)
+	return nil
+}
+
+// deleteTopic deletes the events of the topic together with its consumer groups and other state.
+func deleteTopic(tx bolted.SugaredWriteTx, topic string) error {
+	p := topicEventsPath(topic)
+	if !tx.Exists(p) {
+		return errTopicNotFound
+	}
+	tx.Delete(p)
+	for _, dp := range []dbpath.Path{topicGroupsPath(topic), topicIdempotencyKeysPath(topic), topicSizesPath.Append(topic), replicationCursorsPath.Append(topic), replicationAuditCursorsPath.Append(topic), pendingErasuresPath.Append(topic), topicConfigsPath.Append(topic), schemasPath.Append(topic)} {
+		if tx.Exists(dp) {
+			tx.Delete(dp)
+		}
+	}
+	return nil
+}
+
+func (s *Server) listTopics(w http.ResponseWriter, r *http.Request) {
+	log := s.log.WithValues("method", r.Method, "path", r.URL.Path)
+
+	names := []string{}
+	err := bolted.SugaredRead(s.db, func(tx bolted.SugaredReadTx) error {
+		for _, name := range topicNames(tx) {
+			// principals only see the topics they may read
+			if s.authorized(r.Context(), ScopeRead, name) {
+				names = append(names, name)
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		log.Error(err, "could not list topics")
+		http.Error(w, fmt.Errorf("could not list topics: %w", err).Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(names)
+}
+
+func (s *Server) createTopic(w http.ResponseWriter, r *http.Request) {
+	log := s.log.WithValues("method", r.Method, "path", r.URL.Path)
+
+	topic, err := requestTopic(r)
+	if err != nil {
+		log.Error(err, "invalid topic")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// the body is optional, settings not present in it are left unchanged
+	update := topicConfig{}
+	err = json.NewDecoder(r.Body).Decode(&update)
+	if err != nil && err != io.EOF {
+		log.Error(err, "could not decode topic configuration")
+		http.Error(w, fmt.Errorf("could not decode topic configuration: %w", err).Error(), http.StatusBadRequest)
+		return
+	}
+
+	created := false
+	err = bolted.SugaredWrite(s.db, func(tx bolted.SugaredWriteTx) error {
+		created = createTopic(tx, topic)
+		if update.Compaction == nil {
+			return nil
+		}
+		cfg, err := getTopicConfig(tx, topic)
+		if err != nil {
+			return err
+		}
+		cfg.Compaction = update.Compaction
+		return putTopicConfig(tx, topic, cfg)
+	})
+
+	if err != nil {
+		log.Error(err, "could not create topic", "topic", topic)
+		http.Error(w, fmt.Errorf("could not create topic: %w", err).Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if created {
+		log.Info("topic created", "topic", topic)
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// topicInfo is the effective configuration of a topic.
+type topicInfo struct {
+	Topic      string `json:"topic"`
+	Compaction bool   `json:"compaction"`
+}
+
+func (s *Server) getTopic(w http.ResponseWriter, r *http.Request) {
+	log := s.log.WithValues("method", r.Method, "path", r.URL.Path)
+
+	topic, err := requestTopic(r)
+	if err != nil {
+		log.Error(err, "invalid topic")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	info := topicInfo{Topic: topic}
+	err = bolted.SugaredRead(s.db, func(tx bolted.SugaredReadTx) error {
+		if !tx.Exists(topicEventsPath(topic)) {
+			return errTopicNotFound
+		}
+		cfg, err := getTopicConfig(tx, topic)
+		if err != nil {
+			return err
+		}
+		info.Compaction = s.compactionEnabled(cfg)
+		return nil
+	})
+
+	if errors.Is(err, errTopicNotFound) {
+		http.Error(w, fmt.Errorf("topic %s: %w", topic, err).Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		log.Error(err, "could not get topic", "topic", topic)
+		http.Error(w, fmt.Errorf("could not get topic: %w", err).Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
+
+func (s *Server) deleteTopic(w http.ResponseWriter, r *http.Request) {
+	log := s.log.WithValues("method", r.Method, "path", r.URL.Path)
+
+	topic, err := requestTopic(r)
+	if err != nil {
+		log.Error(err, "invalid topic")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if topic == DefaultTopic {
+		http.Error(w, "the default topic cannot be deleted", http.StatusBadRequest)
+		return
+	}
+
+	err = bolted.SugaredWrite(s.db, func(tx bolted.SugaredWriteTx) error {
+		return deleteTopic(tx, topic)
+	})
+
+	if errors.Is(err, errTopicNotFound) {
+		http.Error(w, fmt.Errorf("topic %s: %w", topic, err).Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		log.Error(err, "could not delete topic", "topic", topic)
+		http.Error(w, fmt.Errorf("could not delete topic: %w", err).Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.forgetSchemas(topic, "", true)
+
+	log.Info("topic deleted", "topic", topic)
+	w.WriteHeader(http.StatusOK)
+}
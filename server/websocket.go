@@ -0,0 +1,273 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/draganm/bolted"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsWriteTimeout   = 10 * time.Second
+	wsPongTimeout    = 60 * time.Second
+	wsPingInterval   = 30 * time.Second
+	wsMaxMessageSize = 16 * 1024 * 1024
+	// wsOutgoingQueueSize bounds the number of messages waiting to be written to a socket.
+	// Once the queue is full, the subscription stops reading events until the socket catches up.
+	wsOutgoingQueueSize = 4
+)
+
+var upgrader = websocket.Upgrader{
+	// the API does not rely on cookies, so cross-origin frontends are allowed
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+func isWebSocketUpgrade(r *http.Request, rm *mux.RouteMatch) bool {
+	return websocket.IsWebSocketUpgrade(r)
+}
+
+// wsRequest is a message sent by the client over the WebSocket.
+type wsRequest struct {
+	// Type is either "publish" or "subscribe".
+	Type      string `json:"type"`
+	RequestID string `json:"requestId,omitempty"`
+
+	// publish
+	Events []json.RawMessage `json:"events,omitempty"`
+	// IdempotencyKey of the published batch, see POST /events.
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
+
+	// subscribe
+	After string `json:"after,omitempty"`
+	Group string `json:"group,omitempty"`
+	Limit int    `json:"limit,omitempty"`
+	// Format of delivered events, either empty for [id, payload] tuples or "envelope".
+	Format string `json:"format,omitempty"`
+}
+
+// wsResponse is a message sent by the server over the WebSocket.
+type wsResponse struct {
+	// Type is one of "published", "subscribed", "events" or "error".
+	Type      string   `json:"type"`
+	RequestID string   `json:"requestId,omitempty"`
+	IDs       []string `json:"ids,omitempty"`
+	// Events are either []event or []eventEnvelope, depending on the subscription format.
+	Events any    `json:"events,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// webSocket serves publishing and subscribing over a single WebSocket connection.
+// Published batches have the same semantics as POST /events, subscriptions
+// push batches of events after the given cursor as they are committed.
+func (s *Server) webSocket(w http.ResponseWriter, r *http.Request) {
+	log := s.log.WithValues("method", r.Method, "path", r.URL.Path)
+
+	topic, err := requestTopic(r)
+	if err != nil {
+		log.Error(err, "invalid topic")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Error(err, "could not upgrade connection")
+		return
+	}
+
+	defer conn.Close()
+
+	conn.SetReadLimit(wsMaxMessageSize)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	outgoing := make(chan wsResponse, wsOutgoingQueueSize)
+
+	send := func(msg wsResponse) bool {
+		select {
+		case outgoing <- msg:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	writerDone := make(chan struct{})
+
+	go func() {
+		defer close(writerDone)
+		// closing the connection unblocks the reader
+		defer conn.Close()
+		defer cancel()
+
+		ping := time.NewTicker(wsPingInterval)
+		defer ping.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(wsWriteTimeout))
+				return
+			case <-ping.C:
+				err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout))
+				if err != nil {
+					log.Error(err, "could not send ping")
+					return
+				}
+			case msg := <-outgoing:
+				conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+				err := conn.WriteJSON(msg)
+				if err != nil {
+					log.Error(err, "could not write message")
+					return
+				}
+			}
+		}
+	}()
+
+	conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+
+	subscribed := false
+
+	for ctx.Err() == nil {
+		req := wsRequest{}
+		err = conn.ReadJSON(&req)
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Error(err, "could not read message")
+			}
+			break
+		}
+
+		switch req.Type {
+		case "publish":
+			if s.isFollower() {
+				// only HTTP writes are forwarded to the leader
+				send(wsResponse{Type: "error", RequestID: req.RequestID, Error: fmt.Sprintf("%s, send them to %s", errFollower.Error(), s.leaderURL.String())})
+				continue
+			}
+			if !s.authorized(ctx, ScopePublish, topic) {
+				send(wsResponse{Type: "error", RequestID: req.RequestID, Error: fmt.Sprintf("not permitted to publish to topic %s", topic)})
+				continue
+			}
+			batch := payloadsBatch(req.Events)
+			batch.IdempotencyKey = req.IdempotencyKey
+			err := batch.validate()
+			if err != nil {
+				send(wsResponse{Type: "error", RequestID: req.RequestID, Error: fmt.Errorf("invalid request: %w", err).Error()})
+				continue
+			}
+			ids, err := s.appendEvents(topic, batch)
+			if err != nil {
+				log.Error(err, "could not store events", "topic", topic)
+				send(wsResponse{Type: "error", RequestID: req.RequestID, Error: fmt.Errorf("could not store events: %w", err).Error()})
+				continue
+			}
+			send(wsResponse{Type: "published", RequestID: req.RequestID, IDs: ids})
+		case "subscribe":
+			if subscribed {
+				send(wsResponse{Type: "error", RequestID: req.RequestID, Error: "already subscribed"})
+				continue
+			}
+
+			if !s.authorized(ctx, ScopeRead, topic) {
+				send(wsResponse{Type: "error", RequestID: req.RequestID, Error: fmt.Sprintf("not permitted to read topic %s", topic)})
+				continue
+			}
+
+			after, err := s.startPosition(topic, req.After, req.Group)
+			if err != nil {
+				send(wsResponse{Type: "error", RequestID: req.RequestID, Error: err.Error()})
+				continue
+			}
+
+			limit := req.Limit
+			if limit <= 0 || limit > maxLimit {
+				limit = maxLimit
+			}
+
+			subscribed = true
+			if !send(wsResponse{Type: "subscribed", RequestID: req.RequestID}) {
+				continue
+			}
+
+			go func() {
+				err := s.subscribe(ctx, topic, after, limit, func(events []event) bool {
+					if req.Format == "envelope" {
+						return send(wsResponse{Type: "events", RequestID: req.RequestID, Events: toEnvelopes(events)})
+					}
+					return send(wsResponse{Type: "events", RequestID: req.RequestID, Events: events})
+				})
+				if err != nil && ctx.Err() == nil {
+					log.Error(err, "subscription failed", "topic", topic)
+					send(wsResponse{Type: "error", RequestID: req.RequestID, Error: err.Error()})
+				}
+			}()
+		default:
+			send(wsResponse{Type: "error", RequestID: req.RequestID, Error: fmt.Sprintf("unsupported message type %q", req.Type)})
+		}
+	}
+
+	cancel()
+	<-writerDone
+
+}
+
+// subscribe calls deliver with batches of at most limit events stored after the given ID,
+// waiting for new events once all stored events have been delivered.
+// Delivery is synchronous, so a slow consumer slows down reading instead of buffering events.
+// It returns when the context is cancelled or deliver returns false.
+func (s *Server) subscribe(ctx context.Context, topic, after string, limit int, deliver func([]event) bool) error {
+	topicPath := topicEventsPath(topic)
+
+	changes, done := s.db.Observe(topicPath.ToMatcher().AppendAnyElementMatcher())
+	defer done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changes:
+		}
+
+		for {
+			var events []event
+			err := bolted.SugaredRead(s.db, func(tx bolted.SugaredReadTx) (err error) {
+				events, err = readEvents(tx, topicPath, after, limit)
+				return err
+			})
+
+			if errors.Is(err, errTopicNotFound) {
+				return fmt.Errorf("topic %s: %w", topic, err)
+			}
+
+			if err != nil {
+				return fmt.Errorf("could not read events: %w", err)
+			}
+
+			if len(events) == 0 {
+				break
+			}
+
+			if !deliver(events) {
+				return nil
+			}
+
+			after = events[len(events)-1].id
+
+			if len(events) < limit {
+				break
+			}
+		}
+	}
+}
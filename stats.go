@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/draganm/event-buffer/client"
+	"github.com/urfave/cli/v2"
+)
+
+var statsCommand = &cli.Command{
+	Name:  "stats",
+	Usage: "print the number of events, their age and the lag of consumer groups per topic",
+	Flags: append([]cli.Flag{
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the statistics as JSON",
+		},
+	}, clientFlags...),
+	Action: func(c *cli.Context) error {
+		cl, err := serverClient(c)
+		if err != nil {
+			return err
+		}
+
+		stats, err := cl.Stats(c.Context)
+		if err != nil {
+			return fmt.Errorf("could not get stats: %w", err)
+		}
+
+		if c.Bool("json") {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(stats)
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "TOPIC\tEVENTS\tBYTES\tOLDEST\tNEWEST")
+		for _, ts := range stats {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\n", ts.Topic, ts.Events, ts.Bytes, eventAge(ts.Oldest), eventAge(ts.Newest))
+		}
+
+		groups := false
+		for _, ts := range stats {
+			for _, g := range ts.Groups {
+				if !groups {
+					fmt.Fprintln(tw, "\nTOPIC\tGROUP\tLAG\tCOMMITTED")
+					groups = true
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", ts.Topic, g.Group, g.Lag, g.ID)
+			}
+		}
+
+		return tw.Flush()
+	},
+}
+
+func eventAge(e *client.EventStats) string {
+	if e == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%s ago", time.Since(e.Time).Truncate(time.Second))
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/draganm/event-buffer/client"
+	"github.com/urfave/cli/v2"
+)
+
+// tailBatchSize is the number of events requested per poll.
+const tailBatchSize = 100
+
+var tailCommand = &cli.Command{
+	Name:  "tail",
+	Usage: "print events as newline delimited JSON",
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:  "topic",
+			Usage: "topic to read, the default topic if not set",
+		},
+		&cli.StringFlag{
+			Name:  "after",
+			Usage: "print events after the event with the ID, all events if not set",
+		},
+		&cli.BoolFlag{
+			Name:    "follow",
+			Aliases: []string{"f"},
+			Usage:   "wait for and print new events until interrupted",
+		},
+		&cli.BoolFlag{
+			Name:  "payload-only",
+			Usage: "print only the payloads of the events",
+		},
+	}, clientFlags...),
+	Action: func(c *cli.Context) error {
+		cl, err := topicClient(c)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(c.Context, syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		enc := json.NewEncoder(os.Stdout)
+		after := c.String("after")
+
+		for {
+			var events []client.Event
+			if c.Bool("follow") {
+				events, err = cl.Poll(ctx, after, tailBatchSize)
+			} else {
+				events, err = cl.ReadEvents(ctx, after, tailBatchSize)
+			}
+
+			if ctx.Err() != nil {
+				// interrupted
+				return nil
+			}
+
+			if err != nil {
+				return fmt.Errorf("could not read events: %w", err)
+			}
+
+			if len(events) == 0 {
+				return nil
+			}
+
+			for _, e := range events {
+				if c.Bool("payload-only") {
+					err = enc.Encode(e.Payload)
+				} else {
+					err = enc.Encode(e)
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+			after = events[len(events)-1].ID
+		}
+	},
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/draganm/event-buffer/server"
+	"github.com/urfave/cli/v2"
+)
+
+func readTokenSecret(fileName string) ([]byte, error) {
+	secret, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("could not read token secret: %w", err)
+	}
+	secret = bytes.TrimSpace(secret)
+	if len(secret) < 32 {
+		return nil, fmt.Errorf("token secret must be at least 32 bytes long")
+	}
+	return secret, nil
+}
+
+var tokenCommand = &cli.Command{
+	Name:  "token",
+	Usage: "issue a token signed with the token secret",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "token-secret-file",
+			EnvVars:  []string{"TOKEN_SECRET_FILE"},
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "subject",
+			Usage:    "name of the token holder",
+			Required: true,
+		},
+		&cli.StringSliceFlag{
+			Name:     "grant",
+			Usage:    "granted scope in the form <publish|read|admin>[:<topic>]",
+			Required: true,
+		},
+		&cli.DurationFlag{
+			Name:  "ttl",
+			Usage: "validity of the token, 0 for tokens that don't expire",
+			Value: 24 * time.Hour,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		secret, err := readTokenSecret(c.String("token-secret-file"))
+		if err != nil {
+			return err
+		}
+
+		p := server.Principal{Name: c.String("subject")}
+		for _, gs := range c.StringSlice("grant") {
+			g, err := server.ParseGrant(gs)
+			if err != nil {
+				return err
+			}
+			p.Grants = append(p.Grants, g)
+		}
+
+		var expires time.Time
+		if c.Duration("ttl") > 0 {
+			expires = time.Now().Add(c.Duration("ttl"))
+		}
+
+		token, err := server.NewHMACTokens(secret).Sign(p, expires)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(token)
+		return nil
+	},
+}